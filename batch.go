@@ -0,0 +1,126 @@
+package v8dispatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// inboundMessage is a MessageSend from Javascript, or a batch of MessageSends sent by V8D.batch.
+type inboundMessage struct {
+	MessageSend
+	Batch []json.RawMessage `json:"batch,omitempty"`
+}
+
+// receiveBatch performs each MessageSend of a batch from Javascript and returns the JSON array of their results.
+// Each result is either {"value": ...} or {"error": {"name": ..., "message": ...}}; a missing handler is an error.
+func (d *MessageDispatcher) receiveBatch(batch []json.RawMessage) string {
+	results := make([]string, len(batch))
+	for i, each := range batch {
+		start := time.Now()
+		var msg MessageSend
+		if err := json.Unmarshal(each, &msg); err != nil {
+			d.logger.Error("not a valid MessageSend", "direction", directionInbound, "err", err)
+			d.observe(msg, directionInbound, start, len(each), "", err)
+			results[i] = batchResult(errorReply(err))
+			continue
+		}
+		// the results are returned even if the messages were sent using V8D.call
+		msg.IsAsynchronous = false
+		reply, err := d.receiveMessage(msg, start, len(each))
+		if err != nil {
+			// such as ErrNoHandler, which is not an error reply for a single MessageSend
+			reply = errorReply(err)
+		}
+		results[i] = batchResult(reply)
+	}
+	return "[" + strings.Join(results, ",") + "]"
+}
+
+// batchResult returns the result object for the reply of one MessageSend of a batch.
+func batchResult(reply string) string {
+	if strings.HasPrefix(reply, errorReplyPrefix) {
+		return `{"error":` + reply[len(errorReplyPrefix):] + `}`
+	}
+	if len(reply) == 0 {
+		return `{"value":null}`
+	}
+	return `{"value":` + reply + `}`
+}
+
+// Batcher collects MessageSends to Javascript that are sent at once, see Batch.
+type Batcher interface {
+	// Call adds a MessageSend to the batch; its result is at the same index in the results of Batch.
+	Call(receiver string, method string, arguments ...interface{})
+}
+
+// batch is the Batcher used by Batch.
+type batch struct {
+	messages []MessageSend
+}
+
+func (b *batch) Call(receiver string, method string, arguments ...interface{}) {
+	b.messages = append(b.messages, MessageSend{
+		Receiver:  receiver,
+		Selector:  method,
+		Arguments: arguments,
+	})
+}
+
+// BatchResult is the result of one MessageSend of a Batch.
+type BatchResult struct {
+	// Value is the return value of the Javascript function.
+	Value interface{}
+	// Err is a *BatchError if the function threw or could not be found.
+	Err error
+}
+
+// BatchError is the error of a MessageSend of a Batch that failed in Javascript.
+type BatchError struct {
+	// Receiver and Selector identify the MessageSend.
+	Receiver, Selector string
+	// Name and Message are those of the Javascript error.
+	Name, Message string
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s.%s: %s: %s", e.Receiver, e.Selector, e.Name, e.Message)
+}
+
+// Batch sends the MessageSends collected by the function to Javascript in one synchronous call
+// and returns their results in the same order. A MessageSend that fails does not stop the others;
+// its error is part of its result. The returned error is set only if the batch itself failed.
+func (d *MessageDispatcher) Batch(collect func(b Batcher)) ([]BatchResult, error) {
+	b := new(batch)
+	collect(b)
+	if len(b.messages) == 0 {
+		return nil, nil
+	}
+	var replies []struct {
+		Value interface{} `json:"value"`
+		Error *struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := d.sendInto(MessageSend{
+		Receiver:  "V8D",
+		Selector:  "performBatch",
+		Arguments: []interface{}{b.messages},
+	}, &replies); err != nil {
+		return nil, err
+	}
+	if len(replies) != len(b.messages) {
+		return nil, fmt.Errorf("batch of %d messages has %d results", len(b.messages), len(replies))
+	}
+	results := make([]BatchResult, len(replies))
+	for i, each := range replies {
+		results[i].Value = each.Value
+		if each.Error != nil {
+			msg := b.messages[i]
+			results[i].Err = &BatchError{Receiver: msg.Receiver, Selector: msg.Selector, Name: each.Error.Name, Message: each.Error.Message}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,119 @@
+package v8dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBatchFromJS(t *testing.T) {
+	dist := newDispatcher(t, WithTimers(), WithPolicy(NewPolicy("batch").Allow("", "double", "fail", "missing")))
+	dist.RegisterFunc("double", func(msg MessageSend) (interface{}, error) {
+		return msg.Arguments[0].(float64) * 2, nil
+	})
+	dist.RegisterFunc("fail", func(msg MessageSend) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	v, err := dist.Eval("batch.js", `
+	var called = false;
+	var results = V8D.batch(function() {
+		V8D.call("", "double", 1);
+		V8D.call("", "fail");
+		V8D.call("", "denied");
+		V8D.callThen("", "double", function(value) { called = value; }, 2);
+		V8D.call("", "missing");
+		setTimeout(function() { called = "later"; }, 1);
+	});
+	[results, called]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(v)
+	want := "[[map[value:2] map[error:map[message:failed name:Error]] map[error:map[message:policy \"batch\" denies .denied name:PolicyError]] map[value:4] map[error:map[message:no handler name:Error]]] 4]"
+	if got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// timers are scheduled in Go but are not part of the results
+	if err := dist.RunEventLoop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dist.PendingTimers(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if v, _ := dist.Get("called"); v != "later" {
+		t.Errorf("got %v want later", v)
+	}
+}
+
+func TestBatchFromJSFlushedByCallReturn(t *testing.T) {
+	dist := newDispatcher(t)
+	count := 0
+	dist.RegisterFunc("increment", func(msg MessageSend) (interface{}, error) {
+		count++
+		return count, nil
+	})
+	v, err := dist.Eval("flush.js", `
+	var seen;
+	var results = V8D.batch(function() {
+		V8D.call("", "increment");
+		seen = V8D.callReturn("", "increment");
+		V8D.call("", "increment");
+	});
+	[results.length, seen]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(v), "[2 2]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// a throwing function discards the batch
+	if _, err := dist.Eval("throw.js", `V8D.batch(function() { V8D.call("", "increment"); throw new Error("stop"); })`); err == nil {
+		t.Fatal("error expected")
+	}
+	if got, want := count, 3; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestBatchFromGo(t *testing.T) {
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("batch.js", `
+	var calc = {
+		square: function(x) { return x * x; },
+		fail: function() { throw new RangeError("out of range"); }
+	};`); err != nil {
+		t.Fatal(err)
+	}
+	results, err := dist.Batch(func(b Batcher) {
+		b.Call("calc", "square", 3)
+		b.Call("calc", "fail")
+		b.Call("calc", "missing")
+		b.Call("V8D", "get", "calc")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(results), 4; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := results[0].Value, 9.0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	var batchErr *BatchError
+	if !errors.As(results[1].Err, &batchErr) {
+		t.Fatalf("got %v want *BatchError", results[1].Err)
+	}
+	if got, want := batchErr.Error(), "calc.fail: RangeError: out of range"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if results[2].Err == nil {
+		t.Error("error expected")
+	}
+	if results[3].Err != nil {
+		t.Error(results[3].Err)
+	}
+	if results, err := dist.Batch(func(b Batcher) {}); err != nil || results != nil {
+		t.Errorf("got %v, %v want nil", results, err)
+	}
+}
@@ -0,0 +1,24 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// env gives access to the environment variables of the v8d command.
+//
+var env = {};
+
+// get returns the value of a variable or null if it is not set.
+//
+env.get = function(name) {
+    return V8D.callReturn("env", "get", name);
+}
+
+// all returns an object with all variables.
+//
+env.all = function() {
+    return V8D.callReturn("env", "all");
+}
+
+process.env = env.all();
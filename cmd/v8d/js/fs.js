@@ -0,0 +1,40 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// fs gives access to files; paths are relative to the working directory of the v8d command.
+//
+var fs = {};
+
+// readFile returns the content of a file as a string.
+//
+fs.readFile = function(path) {
+    return V8D.callReturn("fs", "readFile", path);
+}
+
+// writeFile creates or replaces a file with the string data.
+//
+fs.writeFile = function(path, data) {
+    V8D.callReturn("fs", "writeFile", path, String(data));
+}
+
+// exists returns whether a file or directory exists.
+//
+fs.exists = function(path) {
+    return V8D.callReturn("fs", "exists", path);
+}
+
+// readDir returns the sorted names of the entries of a directory.
+//
+fs.readDir = function(path) {
+    return V8D.callReturn("fs", "readDir", path);
+}
+
+// remove deletes a file or an empty directory.
+//
+fs.remove = function(path) {
+    V8D.callReturn("fs", "remove", path);
+}
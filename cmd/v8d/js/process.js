@@ -0,0 +1,21 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// process gives access to the arguments and the exit code of the v8d command.
+// argv holds "v8d", the script name and the arguments after the script name.
+//
+var process = {
+    "argv": V8D.callReturn("process", "argv"),
+    "exitCode": 0
+};
+
+// exit ends the script with an exit code; default is process.exitCode.
+//
+process.exit = function(code) {
+    V8D.callReturn("process", "exit", code === undefined ? process.exitCode : code);
+    throw new Error("process.exit(" + code + ")");
+}
@@ -0,0 +1,49 @@
+/*
+Command v8d runs Javascript using a MessageDispatcher.
+
+	v8d run [-modules fs,env,timers] [-trace] script.js [arguments]
+
+The script is loaded with the default runtime; built-in modules are opt-in:
+
+	fs      fs.readFile, fs.writeFile, fs.exists, fs.readDir and fs.remove
+	env     env.get, env.all and process.env
+	timers  setTimeout, setInterval, clearTimeout and clearInterval
+
+The global process has argv ("v8d", the script and its arguments), exitCode and exit(code).
+After loading the script, v8d waits until all timers have fired or are cleared.
+The exit code is the argument of process.exit, else process.exitCode; an uncaught error exits with 1.
+
+	v8d repl [-modules fs,env,timers] [-trace] [arguments]
+
+The repl evaluates each input and prints its value as indented JSON; "var x = 1" and function definitions are kept.
+Incomplete input, such as an open function body, continues on the next line.
+Timers that are due shortly after an input fire before the next prompt; later ones fire after a later input.
+Meta-commands are .load file.js, .save file.js (all evaluated input), .handlers, .break, .help and .exit.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "run":
+		os.Exit(runCommand(os.Args[2:]))
+	case "repl":
+		os.Exit(replCommand(os.Args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: v8d run [-modules fs,env,timers] [-trace] script.js [arguments]")
+	fmt.Fprintln(os.Stderr, "       v8d repl [-modules fs,env,timers] [-trace] [arguments]")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+//go:embed js/*.js
+var moduleFS embed.FS
+
+// moduleNames lists the built-in modules that can be enabled; "timers" is provided by v8dispatcher.WithTimers.
+var moduleNames = []string{"fs", "env", "timers"}
+
+// parseModules returns the set of enabled modules from a comma separated list.
+func parseModules(list string) (map[string]bool, error) {
+	enabled := map[string]bool{}
+	for _, each := range strings.Split(list, ",") {
+		name := strings.TrimSpace(each)
+		if len(name) == 0 {
+			continue
+		}
+		known := false
+		for _, other := range moduleNames {
+			known = known || other == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown module: %s (available: %s)", name, strings.Join(moduleNames, ","))
+		}
+		enabled[name] = true
+	}
+	return enabled, nil
+}
+
+// installModule registers the handler of a module and loads its Javascript.
+func installModule(d *v8dispatcher.MessageDispatcher, name string, handler v8dispatcher.MessageSendHandler) error {
+	d.Register(name, handler)
+	source, err := moduleFS.ReadFile("js/" + name + ".js")
+	if err != nil {
+		return err
+	}
+	return d.Worker().Load(name+".js", string(source))
+}
+
+// process is the handler for the "process" module.
+type process struct {
+	argv   []string
+	exited bool
+	code   int
+	cancel context.CancelFunc
+}
+
+// Perform is part of v8dispatcher.MessageSendHandler.
+func (p *process) Perform(msg v8dispatcher.MessageSend) (interface{}, error) {
+	switch msg.Selector {
+	case "argv":
+		return p.argv, nil
+	case "exit":
+		p.exited = true
+		if len(msg.Arguments) > 0 {
+			code, _ := msg.Arguments[0].(float64)
+			p.code = int(code)
+		}
+		p.cancel()
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+}
+
+// fileSystem is the handler for the "fs" module.
+type fileSystem struct{}
+
+// Perform is part of v8dispatcher.MessageSendHandler.
+func (fileSystem) Perform(msg v8dispatcher.MessageSend) (interface{}, error) {
+	if len(msg.Arguments) == 0 {
+		return nil, fmt.Errorf("%s: missing path", msg.Selector)
+	}
+	path, ok := msg.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: path must be a string", msg.Selector)
+	}
+	switch msg.Selector {
+	case "readFile":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case "writeFile":
+		data := ""
+		if len(msg.Arguments) > 1 {
+			data, _ = msg.Arguments[1].(string)
+		}
+		return nil, os.WriteFile(path, []byte(data), 0644)
+	case "exists":
+		_, err := os.Stat(path)
+		return err == nil, nil
+	case "readDir":
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, each := range entries {
+			names = append(names, each.Name())
+		}
+		sort.Strings(names)
+		return names, nil
+	case "remove":
+		return nil, os.Remove(path)
+	default:
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+}
+
+// environment is the handler for the "env" module.
+type environment struct{}
+
+// Perform is part of v8dispatcher.MessageSendHandler.
+func (environment) Perform(msg v8dispatcher.MessageSend) (interface{}, error) {
+	switch msg.Selector {
+	case "get":
+		if len(msg.Arguments) == 0 {
+			return nil, fmt.Errorf("get: missing name")
+		}
+		name, _ := msg.Arguments[0].(string)
+		if value, ok := os.LookupEnv(name); ok {
+			return value, nil
+		}
+		return nil, nil
+	case "all":
+		all := map[string]string{}
+		for _, each := range os.Environ() {
+			if name, value, ok := strings.Cut(each, "="); ok {
+				all[name] = value
+			}
+		}
+		return all, nil
+	default:
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+}
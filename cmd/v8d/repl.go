@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+const replHelp = `.break           discard the incomplete input
+.exit            end the session
+.handlers        list the registered Go handlers
+.help            show this help
+.load file.js    evaluate the content of a file
+.save file.js    write all evaluated input of this session to a file`
+
+// replCommand implements "v8d repl" and returns the exit code.
+func replCommand(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	modules := flags.String("modules", "", "comma separated built-in modules: fs, env, timers")
+	trace := flags.Bool("trace", false, "log all MessageSends")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	enabled, err := parseModules(*modules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, proc, err := newDispatcher(append([]string{"v8d"}, flags.Args()...), enabled, cancel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer d.Close()
+	d.Trace(*trace)
+	r := &repl{dispatcher: d, process: proc, ctx: ctx, out: os.Stdout}
+	return r.run(os.Stdin)
+}
+
+// repl evaluates input line by line and prints the value of each complete input.
+type repl struct {
+	dispatcher *v8dispatcher.MessageDispatcher
+	process    *process
+	ctx        context.Context
+	out        io.Writer
+	pending    []string // lines of incomplete input
+	history    []string // evaluated inputs
+}
+
+// run reads input until the end or until .exit or process.exit and returns the exit code.
+func (r *repl) run(in io.Reader) int {
+	scanner := bufio.NewScanner(in)
+	r.prompt()
+	for scanner.Scan() {
+		if done := r.handle(scanner.Text()); done {
+			return r.process.code
+		}
+		r.prompt()
+	}
+	fmt.Fprintln(r.out)
+	return 0
+}
+
+func (r *repl) prompt() {
+	if len(r.pending) > 0 {
+		fmt.Fprint(r.out, "... ")
+	} else {
+		fmt.Fprint(r.out, "> ")
+	}
+}
+
+// handle processes one line of input and returns whether the session has ended.
+func (r *repl) handle(line string) bool {
+	if len(r.pending) == 0 && strings.HasPrefix(strings.TrimSpace(line), ".") {
+		return r.command(strings.Fields(line))
+	}
+	if strings.TrimSpace(line) == ".break" {
+		r.pending = nil
+		return false
+	}
+	r.pending = append(r.pending, line)
+	source := strings.Join(r.pending, "\n")
+	value, err := r.dispatcher.Eval("repl", source)
+	if err != nil && incomplete(err) {
+		return false
+	}
+	r.pending = nil
+	r.print(value, err)
+	if err != nil {
+		return r.process.exited
+	}
+	r.history = append(r.history, source)
+	r.fireTimers()
+	return r.process.exited
+}
+
+// timersWait is how long the repl fires timers after each input.
+const timersWait = 50 * time.Millisecond
+
+// fireTimers fires the timers that are due within timersWait; later ones, such as intervals, stay scheduled.
+func (r *repl) fireTimers() {
+	ctx, cancel := context.WithTimeout(r.ctx, timersWait)
+	defer cancel()
+	if err := r.dispatcher.RunEventLoop(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		r.print(nil, err)
+	}
+}
+
+// command performs a meta-command and returns whether the session has ended.
+func (r *repl) command(fields []string) bool {
+	arg := ""
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+	switch fields[0] {
+	case ".exit":
+		return true
+	case ".break":
+		// nothing pending
+	case ".help":
+		fmt.Fprintln(r.out, replHelp)
+	case ".handlers":
+		for _, each := range r.dispatcher.Handlers() {
+			fmt.Fprintln(r.out, each)
+		}
+	case ".load":
+		source, err := os.ReadFile(arg)
+		if err != nil {
+			r.print(nil, err)
+			return false
+		}
+		value, err := r.dispatcher.Eval(arg, string(source))
+		r.print(value, err)
+		if err == nil {
+			r.history = append(r.history, string(source))
+		}
+	case ".save":
+		content := strings.Join(r.history, "\n")
+		if len(r.history) > 0 {
+			content += "\n"
+		}
+		if err := os.WriteFile(arg, []byte(content), 0644); err != nil {
+			r.print(nil, err)
+		}
+	default:
+		fmt.Fprintf(r.out, "unknown command: %s (try .help)\n", fields[0])
+	}
+	return r.process.exited
+}
+
+// print writes the error or the pretty-printed value.
+func (r *repl) print(value interface{}, err error) {
+	if err != nil {
+		// process.exit aborts by throwing and cancels the event loop
+		if !r.process.exited {
+			fmt.Fprintln(r.out, err)
+		}
+		return
+	}
+	fmt.Fprintln(r.out, pretty(value))
+}
+
+// pretty returns the indented JSON of a value; nil is shown as undefined.
+func pretty(value interface{}) string {
+	if value == nil {
+		return "undefined"
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// incomplete returns whether the error is caused by input that needs more lines.
+func incomplete(err error) bool {
+	var evalErr *v8dispatcher.EvalError
+	if !errors.As(err, &evalErr) || evalErr.Name != "SyntaxError" {
+		return false
+	}
+	return strings.Contains(evalErr.Message, "end of input") || strings.Contains(evalErr.Message, "Unterminated template")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestREPL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, proc, err := newDispatcher([]string{"v8d"}, map[string]bool{"timers": true}, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(t.TempDir(), "session.js")
+	out := new(bytes.Buffer)
+	r := &repl{dispatcher: d, process: proc, ctx: ctx, out: out}
+	input := strings.Join([]string{
+		"var x = 1",
+		"function inc(n) {",
+		"  return n + x;",
+		"}",
+		"inc(41)",
+		"({a: [1]})",
+		"undefinedFunction()",
+		".handlers",
+		".save " + saved,
+		"process.exit(3)",
+		"x",
+	}, "\n")
+	if got, want := r.run(strings.NewReader(input)), 3; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	for _, each := range []string{"... ", "42", "{\n  \"a\": [\n    1\n  ]\n}", "ReferenceError", "process\n", "V8D.timers"} {
+		if !strings.Contains(out.String(), each) {
+			t.Errorf("missing %q in %s", each, out.String())
+		}
+	}
+	data, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "var x = 1\nfunction inc(n) {\n  return n + x;\n}\ninc(41)\n({a: [1]})\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestREPLInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, proc, err := newDispatcher([]string{"v8d"}, map[string]bool{"timers": true}, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	out := new(bytes.Buffer)
+	r := &repl{dispatcher: d, process: proc, ctx: ctx, out: out}
+	input := strings.Join([]string{
+		"var ticks = 0",
+		"var id = setInterval(function() { ticks++; }, 1000)",
+		"setTimeout(function() { ticks = -100; }, 0)",
+		"ticks",
+		"clearInterval(id)",
+	}, "\n")
+	done := make(chan int)
+	go func() { done <- r.run(strings.NewReader(input)) }()
+	select {
+	case code := <-done:
+		if got, want := code, 0; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("repl blocked by setInterval")
+	}
+	// the due timeout fired, the interval did not yet
+	if !strings.Contains(out.String(), "> -100\n") {
+		t.Errorf("missing -100 in %s", out.String())
+	}
+	if got, want := d.PendingTimers(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
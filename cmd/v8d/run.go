@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+// runCommand implements "v8d run" and returns the exit code.
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	modules := flags.String("modules", "", "comma separated built-in modules: fs, env, timers")
+	trace := flags.Bool("trace", false, "log all MessageSends")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		usage()
+		return 2
+	}
+	enabled, err := parseModules(*modules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return runScript(os.Stderr, flags.Arg(0), string(source), flags.Args()[1:], enabled, *trace)
+}
+
+// runScript loads the script in a new MessageDispatcher, runs the event loop and returns the exit code.
+func runScript(stderr io.Writer, name, source string, args []string, enabled map[string]bool, trace bool) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, proc, err := newDispatcher(append([]string{"v8d", name}, args...), enabled, cancel)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer d.Close()
+	d.Trace(trace)
+
+	err = d.Worker().Load(name, source)
+	if err == nil && !proc.exited {
+		err = d.RunEventLoop(ctx)
+	}
+	// process.exit aborts the script by throwing
+	if proc.exited {
+		return proc.code
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return exitCode(d)
+}
+
+// newDispatcher returns a MessageDispatcher with the process module and the enabled built-in modules.
+// The cancel function is called when the script calls process.exit.
+func newDispatcher(argv []string, enabled map[string]bool, cancel context.CancelFunc) (*v8dispatcher.MessageDispatcher, *process, error) {
+	options := []v8dispatcher.Option{}
+	if enabled["timers"] {
+		options = append(options, v8dispatcher.WithTimers())
+	}
+	d, err := v8dispatcher.NewMessageDispatcher(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	proc := &process{argv: argv, cancel: cancel}
+	if err := installModule(d, "process", proc); err != nil {
+		return nil, nil, err
+	}
+	if enabled["fs"] {
+		if err := installModule(d, "fs", fileSystem{}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if enabled["env"] {
+		if err := installModule(d, "env", environment{}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return d, proc, nil
+}
+
+// exitCode returns the value of process.exitCode.
+func exitCode(d *v8dispatcher.MessageDispatcher) int {
+	value, err := d.Get("process")
+	if err != nil {
+		return 1
+	}
+	if proc, ok := value.(map[string]interface{}); ok {
+		if code, ok := proc["exitCode"].(float64); ok {
+			return int(code)
+		}
+	}
+	return 0
+}
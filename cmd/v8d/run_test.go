@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunScriptExitCode(t *testing.T) {
+	for _, each := range []struct {
+		source string
+		want   int
+	}{
+		{`process.exitCode = process.argv.length;`, 3},
+		{`process.exit(4); process.exitCode = 5;`, 4},
+		{`setTimeout(function() { process.exit(6); }, 1); process.exitCode = 7;`, 6},
+		{`setTimeout(function() { throw new Error("boom"); }, 1);`, 1},
+		{`undefinedFunction();`, 1},
+	} {
+		stderr := new(bytes.Buffer)
+		enabled := map[string]bool{"timers": true}
+		if got, want := runScript(stderr, "test.js", each.source, []string{"arg"}, enabled, false), each.want; got != want {
+			t.Errorf("%s: got %v want %v (%s)", each.source, got, want, stderr.String())
+		}
+	}
+}
+
+func TestRunScriptModules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	t.Setenv("V8D_TEST", "hello")
+	source := `
+	fs.writeFile(process.argv[2], env.get("V8D_TEST") + " " + process.env.V8D_TEST);
+	if (!fs.exists(process.argv[2])) { process.exit(3); }
+	`
+	stderr := new(bytes.Buffer)
+	enabled, err := parseModules("fs,env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := runScript(stderr, "test.js", source, []string{file}, enabled, false), 0; got != want {
+		t.Fatalf("got %v want %v (%s)", got, want, stderr.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "hello hello"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, err := parseModules("net"); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Command v8dtest runs Javascript tests written with describe, it and expect.
+
+	v8dtest [-config mocks.json] [-format text|json|junit] [dir]
+
+All *_test.js files in dir (default is the current directory) are run, each in a fresh MessageDispatcher.
+The config file is JSON that lists library scripts to load first and the answers of mocked Go handlers:
+
+	{
+		"load": ["lib/*.js"],
+		"mocks": [
+			{"receiver": "someApi", "selector": "now", "return": "2016-02-08"},
+			{"receiver": "", "selector": "handleEvent", "arguments": [42], "error": "not now"}
+		]
+	}
+
+A test file looks like:
+
+	describe("someApi", function() {
+		it("returns the date", function() {
+			expect(V8D.callReturn("someApi","now")).toBe("2016-02-08");
+		});
+	});
+
+Matchers are toBe, toEqual, toBeTruthy, toBeFalsy, toContain and toThrow; prefix with "not." to negate.
+The json format is compatible with "go test -json"; the junit format is JUnit XML.
+The exit code is 1 if any test failed.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	configFile = flag.String("config", "", "JSON file with load patterns and mocks")
+	format     = flag.String("format", "text", "output format: text, json or junit")
+)
+
+func main() {
+	flag.Parse()
+	os.Exit(run(os.Stdout, flag.Arg(0)))
+}
+
+func run(w io.Writer, dir string) int {
+	if len(dir) == 0 {
+		dir = "."
+	}
+	report, ok := map[string]func(io.Writer, []fileResult) error{
+		"text":  reportText,
+		"json":  reportJSON,
+		"junit": reportJUnit,
+	}[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format: %s\n", *format)
+		return 2
+	}
+	config, err := readConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	files, err := testFiles(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	results := []fileResult{}
+	failed := false
+	for _, each := range files {
+		result := runFile(dir, each, config)
+		failed = failed || result.Failed()
+		results = append(results, result)
+	}
+	if err := report(w, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
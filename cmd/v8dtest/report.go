@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// reportText writes the results like "go test -v".
+func reportText(w io.Writer, files []fileResult) error {
+	for _, file := range files {
+		for _, each := range file.Results {
+			fmt.Fprintf(w, "=== RUN   %s\n", each.FullName())
+			for _, line := range each.Output {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+			if each.Passed {
+				fmt.Fprintf(w, "--- PASS: %s (%.2fs)\n", each.FullName(), each.Elapsed.Seconds())
+			} else {
+				fmt.Fprintf(w, "--- FAIL: %s (%.2fs)\n    %s\n", each.FullName(), each.Elapsed.Seconds(), each.Error)
+			}
+		}
+		if file.Err != nil {
+			fmt.Fprintf(w, "%s: %v\n", file.File, file.Err)
+		}
+		status := "ok  "
+		if file.Failed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.3fs\n", status, file.File, file.Elapsed.Seconds())
+	}
+	return nil
+}
+
+// testEvent is the event written by "go test -json", see "go doc test2json".
+type testEvent struct {
+	Time    time.Time `json:",omitempty"`
+	Action  string
+	Package string  `json:",omitempty"`
+	Test    string  `json:",omitempty"`
+	Elapsed float64 `json:",omitempty"`
+	Output  string  `json:",omitempty"`
+}
+
+// reportJSON writes the results as "go test -json" events; each test file is reported as a package.
+func reportJSON(w io.Writer, files []fileResult) error {
+	enc := json.NewEncoder(w)
+	now := time.Now()
+	emit := func(e testEvent) error {
+		e.Time = now
+		return enc.Encode(e)
+	}
+	for _, file := range files {
+		pkg := file.File
+		for _, each := range file.Results {
+			name := each.FullName()
+			events := []testEvent{
+				{Action: "run", Package: pkg, Test: name},
+				{Action: "output", Package: pkg, Test: name, Output: fmt.Sprintf("=== RUN   %s\n", name)},
+			}
+			for _, line := range each.Output {
+				events = append(events, testEvent{Action: "output", Package: pkg, Test: name, Output: "    " + line + "\n"})
+			}
+			action, status := "pass", "PASS"
+			if !each.Passed {
+				action, status = "fail", "FAIL"
+				events = append(events, testEvent{Action: "output", Package: pkg, Test: name, Output: "    " + each.Error + "\n"})
+			}
+			events = append(events,
+				testEvent{Action: "output", Package: pkg, Test: name, Output: fmt.Sprintf("--- %s: %s (%.2fs)\n", status, name, each.Elapsed.Seconds())},
+				testEvent{Action: action, Package: pkg, Test: name, Elapsed: each.Elapsed.Seconds()})
+			for _, e := range events {
+				if err := emit(e); err != nil {
+					return err
+				}
+			}
+		}
+		if file.Err != nil {
+			if err := emit(testEvent{Action: "output", Package: pkg, Output: file.Err.Error() + "\n"}); err != nil {
+				return err
+			}
+		}
+		action := "pass"
+		if file.Failed() {
+			action = "fail"
+		}
+		if err := emit(testEvent{Action: action, Package: pkg, Elapsed: file.Elapsed.Seconds()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+	Error    *junitFailure   `xml:"error,omitempty"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// reportJUnit writes the results as JUnit XML; each test file is a testsuite.
+func reportJUnit(w io.Writer, files []fileResult) error {
+	doc := junitTestSuites{}
+	for _, file := range files {
+		suite := junitTestSuite{
+			Name:  file.File,
+			Tests: len(file.Results),
+			Time:  fmt.Sprintf("%.3f", file.Elapsed.Seconds()),
+		}
+		if file.Err != nil {
+			suite.Errors = 1
+			suite.Error = &junitFailure{Message: file.Err.Error()}
+		}
+		for _, each := range file.Results {
+			tc := junitTestCase{
+				ClassName: each.Suite,
+				Name:      each.Name,
+				Time:      fmt.Sprintf("%.3f", each.Elapsed.Seconds()),
+				SystemOut: strings.Join(each.Output, "\n"),
+			}
+			if !each.Passed {
+				suite.Failures++
+				tc.Failure = &junitFailure{Message: each.Error, Contents: each.Stack}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		doc.Suites = append(doc.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
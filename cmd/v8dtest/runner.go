@@ -0,0 +1,181 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emicklei/v8dispatcher"
+	"github.com/emicklei/v8dispatcher/v8dispatchertest"
+)
+
+//go:embed testing.js
+var testingSource string
+
+// Config is the content of the file given with -config.
+type Config struct {
+	// Load lists the patterns (relative to the test directory) of library scripts that are loaded before each test file.
+	Load []string `json:"load"`
+	// Mocks configure the answers of Go handlers.
+	Mocks []MockConfig `json:"mocks"`
+}
+
+// MockConfig describes an expected MessageSend and its answer, see v8dispatchertest.Expectation.
+type MockConfig struct {
+	Receiver string `json:"receiver"`
+	Selector string `json:"selector"`
+	// Arguments, if present, restrict the mock to MessageSends with these arguments.
+	Arguments []interface{} `json:"arguments,omitempty"`
+	Return    interface{}   `json:"return,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// readConfig returns the Config from a JSON file; an empty name returns an empty Config.
+func readConfig(name string) (Config, error) {
+	var c Config
+	if len(name) == 0 {
+		return c, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
+
+// testResult is the outcome of one "it".
+type testResult struct {
+	Suite   string `json:"suite"`
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Error   string `json:"error"`
+	Stack   string `json:"stack"`
+	Output  []string
+	Elapsed time.Duration
+}
+
+// FullName returns the name as used by go test: suites and name separated by slashes, spaces replaced by underscores.
+func (r testResult) FullName() string {
+	name := r.Name
+	if len(r.Suite) > 0 {
+		name = r.Suite + "/" + name
+	}
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// fileResult is the outcome of all tests in one file.
+type fileResult struct {
+	File    string
+	Results []testResult
+	// Err is set if the file could not be loaded or run.
+	Err     error
+	Elapsed time.Duration
+}
+
+// Failed returns whether the file could not be run or any of its tests failed.
+func (f fileResult) Failed() bool {
+	if f.Err != nil {
+		return true
+	}
+	for _, each := range f.Results {
+		if !each.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// testFiles returns the sorted names of all *_test.js files in dir.
+func testFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_test.js"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// runFile runs all tests of a file in a fresh dispatcher.
+func runFile(dir, file string, config Config) fileResult {
+	start := time.Now()
+	result := fileResult{File: file}
+	result.Results, result.Err = runTests(dir, file, config)
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+func runTests(dir, file string, config Config) ([]testResult, error) {
+	d, err := v8dispatcher.NewMessageDispatcher(v8dispatcher.WithBootstrapScripts(v8dispatcher.Script{
+		Name:   "testing.js",
+		Source: testingSource,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	console := v8dispatchertest.CaptureConsole(d)
+	mock := v8dispatchertest.NewMock(d)
+	for _, each := range config.Mocks {
+		e := mock.Expect(each.Receiver, each.Selector).Return(each.Return)
+		if each.Arguments != nil {
+			e.WithArguments(each.Arguments...)
+		}
+		if len(each.Error) > 0 {
+			e.ReturnError(errors.New(each.Error))
+		}
+	}
+	if len(config.Load) > 0 {
+		if err := d.LoadDir(dir, config.Load...); err != nil {
+			return nil, err
+		}
+	}
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Worker().Load(file, string(source)); err != nil {
+		return nil, err
+	}
+	count, err := d.CallReturn("V8DTest", "count")
+	if err != nil {
+		return nil, err
+	}
+	n, _ := count.(float64)
+	results := []testResult{}
+	for i := 0; i < int(n); i++ {
+		logged := len(console.Entries())
+		start := time.Now()
+		reply, err := d.CallReturn("V8DTest", "run", i)
+		if err != nil {
+			return results, err
+		}
+		var each testResult
+		if err := remarshal(reply, &each); err != nil {
+			return results, err
+		}
+		each.Elapsed = time.Since(start)
+		for _, entry := range console.Entries()[logged:] {
+			each.Output = append(each.Output, entry.Text())
+		}
+		results = append(results, each)
+	}
+	return results, nil
+}
+
+// remarshal decodes a value, as returned by CallReturn, into a Go value.
+func remarshal(v interface{}, target interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
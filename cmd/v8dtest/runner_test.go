@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const someApiTest = `
+describe("someApi", function() {
+	it("returns now", function() {
+		console.log("calling now");
+		expect(V8D.callReturn("someApi","now")).toBe("monday");
+	});
+	it("fails", function() {
+		expect([1,2]).not.toContain(2);
+	});
+});
+`
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "someApi_test.js")
+	if err := os.WriteFile(file, []byte(someApiTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{Mocks: []MockConfig{{Receiver: "someApi", Selector: "now", Return: "monday"}}}
+	result := runFile(dir, file, config)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if got, want := len(result.Results), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	pass, fail := result.Results[0], result.Results[1]
+	if !pass.Passed {
+		t.Errorf("pass expected: %v", pass.Error)
+	}
+	if got, want := pass.FullName(), "someApi/returns_now"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := strings.Join(pass.Output, ""), "calling now"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if fail.Passed {
+		t.Error("fail expected")
+	}
+	if !result.Failed() {
+		t.Error("file must fail")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := reportJSON(buf, []fileResult{result}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var last testEvent
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := last.Action, "fail"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	buf.Reset()
+	if err := reportJUnit(buf, []fileResult{result}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `tests="2" failures="1"`) {
+		t.Errorf("unexpected junit: %s", buf.String())
+	}
+}
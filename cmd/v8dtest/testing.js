@@ -0,0 +1,147 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// V8DTest collects the tests defined with describe and it; the v8dtest command runs them one by one.
+//
+var V8DTest = {"tests": [], "suites": []};
+
+// describe groups tests under a name; suites can be nested.
+//
+function describe(name, body) {
+    V8DTest.suites.push(name);
+    try {
+        body();
+    } finally {
+        V8DTest.suites.pop();
+    }
+}
+
+// it defines a test with a name and a body function.
+//
+function it(name, body) {
+    V8DTest.tests.push({
+        "suite": V8DTest.suites.join(" "),
+        "name": name,
+        "body": body
+    });
+}
+
+// count returns the number of tests defined.
+//
+V8DTest.count = function() {
+    return V8DTest.tests.length;
+}
+
+// run performs the test at the index and returns its result.
+//
+V8DTest.run = function(index) {
+    var test = V8DTest.tests[index];
+    var result = {"suite": test.suite, "name": test.name, "passed": true};
+    try {
+        test.body();
+    } catch (e) {
+        result.passed = false;
+        result.error = (e && e.message !== undefined) ? String(e.message) : String(e);
+        if (e && e.stack) {
+            result.stack = String(e.stack);
+        }
+    }
+    return result;
+}
+
+// format returns a readable representation of a value.
+//
+V8DTest.format = function(value) {
+    if (typeof value === "function") {
+        return "function";
+    }
+    try {
+        return JSON.stringify(value);
+    } catch (e) {
+        return String(value);
+    }
+}
+
+// equal compares two values by their structure.
+//
+V8DTest.equal = function(a, b) {
+    if (a === b) {
+        return true;
+    }
+    if (a === null || b === null || typeof a !== "object" || typeof b !== "object") {
+        return false;
+    }
+    if (Array.isArray(a) !== Array.isArray(b)) {
+        return false;
+    }
+    var keys = Object.keys(a);
+    if (keys.length !== Object.keys(b).length) {
+        return false;
+    }
+    for (var i = 0; i < keys.length; i++) {
+        if (!V8DTest.equal(a[keys[i]], b[keys[i]])) {
+            return false;
+        }
+    }
+    return true;
+}
+
+// Expectation is a constructor for the matchers on an actual value.
+//
+V8DTest.Expectation = function(actual, negated) {
+    this.actual = actual;
+    this.negated = negated;
+    if (!negated) {
+        this.not = new V8DTest.Expectation(actual, true);
+    }
+}
+
+V8DTest.Expectation.prototype = {
+    check: function(ok, description) {
+        if (ok === this.negated) {
+            throw new Error("expected " + V8DTest.format(this.actual) + (this.negated ? " not " : " ") + description);
+        }
+    },
+    toBe: function(expected) {
+        this.check(this.actual === expected, "to be " + V8DTest.format(expected));
+    },
+    toEqual: function(expected) {
+        this.check(V8DTest.equal(this.actual, expected), "to equal " + V8DTest.format(expected));
+    },
+    toBeTruthy: function() {
+        this.check(!!this.actual, "to be truthy");
+    },
+    toBeFalsy: function() {
+        this.check(!this.actual, "to be falsy");
+    },
+    toContain: function(expected) {
+        var found = false;
+        if (typeof this.actual === "string") {
+            found = this.actual.indexOf(expected) !== -1;
+        } else if (Array.isArray(this.actual)) {
+            for (var i = 0; i < this.actual.length; i++) {
+                found = found || V8DTest.equal(this.actual[i], expected);
+            }
+        }
+        this.check(found, "to contain " + V8DTest.format(expected));
+    },
+    toThrow: function() {
+        var thrown = false;
+        try {
+            this.actual();
+        } catch (e) {
+            thrown = true;
+        }
+        this.check(thrown, "to throw");
+    }
+}
+
+// expect returns the matchers for an actual value.
+//
+function expect(actual) {
+    return new V8DTest.Expectation(actual, false);
+}
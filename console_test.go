@@ -1,10 +1,16 @@
 package v8dispatcher
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+)
 
 // clear && go test -v -test.run=TestConsole
 func TestConsole(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	capture := &recorder{}
 	dist.Register("console", capture)
 	err := dist.Worker().Load("console.js", `
@@ -26,3 +32,75 @@ func TestConsole(t *testing.T) {
 		t.Errorf("got %v want %v", got, want)
 	}
 }
+
+func TestConsoleLog(t *testing.T) {
+	defaultLog := Log
+	defer func() { Log = defaultLog }()
+	var level, text string
+	var kvs []interface{}
+	Log = func(l, m string, kv ...interface{}) {
+		level, text, kvs = l, m, kv
+	}
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("hello.js", `console.warn("a", "b", "c");`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := level, "warn"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := text, "a b c"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := fmt.Sprint(kvs), "[script hello.js line 1]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestConsoleSlog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	dist := newDispatcher(t, WithConsoleLogger(logger))
+	err := dist.Worker().Load("levels.js", `
+		console.warn("low", "disk");
+		console.count("hits");
+		console.assert(1 == 2, "math");
+		console.table([{"a":1}]);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []map[string]interface{}{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var each map[string]interface{}
+		if err := dec.Decode(&each); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, each)
+	}
+	if got, want := len(records), 4; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	warn := records[0]
+	if got, want := warn["level"], "WARN"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := warn["msg"], "low disk"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := warn["script"], "levels.js"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := warn["line"], float64(2); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := records[1]["count"], float64(1); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := records[2]["level"], "ERROR"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, ok := records[3]["data"]; !ok {
+		t.Error("table data expected")
+	}
+}
@@ -0,0 +1,107 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithDeterminism makes script runs reproducible: Math.random and V8D.uuid are seeded (see js/deterministic.js),
+// "new Date()" and Date.now use a virtual clock that starts at the given time and timers are enabled (see WithTimers)
+// but only fire when the virtual time is advanced, see Advance and RunEventLoop.
+func WithDeterminism(seed int64, start time.Time) Option {
+	return func(c *config) {
+		c.timers = true
+		c.determinism = &determinism{seed: seed, start: start}
+	}
+}
+
+// determinism holds the settings of WithDeterminism.
+type determinism struct {
+	seed  int64
+	start time.Time
+}
+
+// scripts returns the runtime scripts that replace Math.random and Date.
+func (m *determinism) scripts() []Script {
+	return []Script{
+		{Name: "deterministic.js", Source: runtimeSource("deterministic.js")},
+		{Name: "seed.js", Source: fmt.Sprintf("V8D.random.seed(%d);", uint32(m.seed))},
+	}
+}
+
+// random returns the seeded source of function references and V8D.uuid values.
+func (m *determinism) random() io.Reader {
+	return rand.New(rand.NewSource(m.seed))
+}
+
+// virtualClock is the MessageSendHandler for "V8D.clock" that only moves when advanced.
+type virtualClock struct {
+	mutex sync.Mutex
+	time  time.Time
+}
+
+// Perform is part of MessageSendHandler.
+func (c *virtualClock) Perform(msg MessageSend) (interface{}, error) {
+	if msg.Selector != "now" {
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+	return c.now().UnixMilli(), nil
+}
+
+func (c *virtualClock) now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.time
+}
+
+// reset sets the time, also if it is earlier than the current time.
+func (c *virtualClock) reset(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.time = t
+}
+
+// moveTo sets the time unless it is earlier than the current time.
+func (c *virtualClock) moveTo(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if t.After(c.time) {
+		c.time = t
+	}
+}
+
+// errNotDeterministic is returned by Advance if WithDeterminism was not used.
+var errNotDeterministic = errors.New("Advance requires WithDeterminism")
+
+// Now returns the time of the virtual clock if WithDeterminism was used, the current time otherwise.
+func (d *MessageDispatcher) Now() time.Time {
+	if d.clock == nil {
+		return time.Now()
+	}
+	return d.clock.now()
+}
+
+// Advance moves the virtual clock forward and fires, in order, all timers that are due until then.
+// It returns the error of a failing timer function; the clock is then at the due time of that timer.
+func (d *MessageDispatcher) Advance(duration time.Duration) error {
+	if d.clock == nil {
+		return errNotDeterministic
+	}
+	until := d.clock.now().Add(duration)
+	for {
+		t, ok := d.timers.next()
+		if !ok || t.due.After(until) {
+			break
+		}
+		d.clock.moveTo(t.due)
+		if err := d.fireTimer(t); err != nil {
+			return err
+		}
+	}
+	d.clock.moveTo(until)
+	return nil
+}
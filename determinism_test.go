@@ -0,0 +1,69 @@
+package v8dispatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeterminism(t *testing.T) {
+	start := time.Date(2016, 2, 8, 12, 0, 0, 0, time.UTC)
+	run := func() []interface{} {
+		dist := newDispatcher(t, WithDeterminism(42, start))
+		v, err := dist.Eval("random.js", `[Math.random(), Math.random(), V8D.uuid(), Date.now(), new Date().getTime(), new Date(0).getTime()]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.([]interface{})
+	}
+	first, second := run(), run()
+	for i := range first {
+		if got, want := second[i], first[i]; got != want {
+			t.Errorf("%d: got %v want %v", i, got, want)
+		}
+	}
+	if first[0] == first[1] {
+		t.Error("random values expected")
+	}
+	if got, want := first[3], float64(start.UnixMilli()); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := first[5], float64(0); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	start := time.Date(2016, 2, 8, 12, 0, 0, 0, time.UTC)
+	dist := newDispatcher(t, WithDeterminism(1, start))
+	if err := dist.Worker().Load("timers.js", `
+	var fired = [];
+	setTimeout(function() { fired.push("timeout@" + (Date.now() - start)); }, 1500);
+	var id = setInterval(function() { fired.push("interval@" + (Date.now() - start)); }, 1000);
+	var start = Date.now();`); err != nil {
+		t.Fatal(err)
+	}
+	if err := dist.Advance(999 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dist.PendingTimers(), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := dist.Advance(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	fired, _ := GetAs[[]string](dist, "fired")
+	if got, want := len(fired), 3; got != want {
+		t.Fatalf("got %v want %v", fired, want)
+	}
+	for i, want := range []string{"interval@1000", "timeout@1500", "interval@2000"} {
+		if got := fired[i]; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	if got, want := dist.Now(), start.Add(2999*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := newDispatcher(t).Advance(time.Second); err == nil {
+		t.Error("error expected")
+	}
+}
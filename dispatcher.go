@@ -1,9 +1,14 @@
 package v8dispatcher
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ry/v8worker"
 )
@@ -24,33 +29,142 @@ type MessageDispatcher struct {
 	messageHandlers     map[string]MessageSendHandler
 	worker              *v8worker.Worker
 	traceEnabled        bool
+	scriptSources       []scriptSource
+	options             []Option
+	logger              *slog.Logger
+	metrics             Metrics
+	tracer              *tracer
+	recorder            *messageRecorder
+	replayer            *Replayer
+	timers              *timerQueue
+	clock               *virtualClock
+	ids                 *idAllocator
+	closeHooks          []func()
+	closed              bool
+	limits              Limits
+	policy              *Policy
+	quotas              *quotaLimiter
+	// callDepth is the number of MessageSends being performed
+	callDepth int
 }
 
+// ErrNoHandler is reported if no handler is registered for a MessageSend from Javascript.
+var ErrNoHandler = errors.New("no handler")
+
+const (
+	// directionInbound is used for MessageSends from Javascript to Go.
+	directionInbound = "inbound"
+	// directionOutbound is used for MessageSends from Go to Javascript.
+	directionOutbound = "outbound"
+)
+
 // NewMessageDispatcher returns a new MessageDispatcher initialize with empty handlers and a v8worker.
-func NewMessageDispatcher() *MessageDispatcher {
+// The Javascript runtime and bootstrap scripts are loaded as configured by the options.
+// An error is returned if any of these scripts fails to load or if the runtime is not compatible.
+func NewMessageDispatcher(options ...Option) (*MessageDispatcher, error) {
+	c := newConfig(options)
 	d := &MessageDispatcher{
 		messageHandlerFuncs: map[string]MessageSendHandlerFunc{},
 		messageHandlers:     map[string]MessageSendHandler{},
 		traceEnabled:        false,
+		options:             options,
+		logger:              c.logger,
+		metrics:             c.metrics,
+		replayer:            c.replayer,
+		limits:              c.limits,
+		policy:              c.policy,
+	}
+	if c.recording != nil {
+		d.recorder = &messageRecorder{writer: c.recording}
+	}
+	if c.spanExporter != nil {
+		d.tracer = &tracer{exporter: c.spanExporter}
+	}
+	d.ids = newIDAllocator(defaultRandom)
+	d.Register("V8D.ids", d.ids)
+	if c.timers {
+		d.timers = newTimerQueue()
+		d.Register("V8D.timers", d.timers)
+	}
+	if c.determinism != nil {
+		d.clock = &virtualClock{}
+		d.timers.now = d.clock.now
+		d.Register("V8D.clock", d.clock)
+	}
+	if err := d.start(c); err != nil {
+		return nil, err
+	}
+	if c.console {
+		// install default console handling
+		for each := range consoleLevels {
+			d.RegisterFunc("console."+each, c.consoleHandler)
+		}
+	}
+	return d, nil
+}
+
+// start creates a new worker and loads the runtime and bootstrap scripts.
+// The state of the handlers for the runtime (timers, clock, references) and the usage of quotas are reset.
+func (d *MessageDispatcher) start(c *config) error {
+	if d.timers != nil {
+		d.timers.clear()
+	}
+	if c.determinism != nil {
+		d.clock.reset(c.determinism.start)
+		d.ids.reset(c.determinism.random())
+	} else {
+		d.ids.reset(defaultRandom)
+	}
+	if len(c.quotas) > 0 {
+		d.quotas = newQuotaLimiter(c.quotas, c.quotaAlert)
 	}
 	w := v8worker.New(d.Receive, d.ReceiveSync)
 	d.worker = w
 	// load scripts
-	for _, each := range []struct {
-		name   string
-		source string
-	}{
-		{"registry.js", registry_js()},
-		{"setup.js", setup_js()},
-		{"console.js", console_js()},
-	} {
-		if err := w.Load(each.name, each.source); err != nil {
-			Log("error", "script load error", "source", each.name, "err", err)
+	scripts := append([]Script{}, c.runtime...)
+	if c.timers {
+		scripts = append(scripts, Script{Name: "timers.js", Source: runtimeSource("timers.js")})
+	}
+	if c.determinism != nil {
+		scripts = append(scripts, c.determinism.scripts()...)
+	}
+	scripts = append(scripts, c.bootstrap...)
+	for _, each := range scripts {
+		if !c.console && each.Name == "console.js" {
+			continue
+		}
+		if err := w.Load(each.Name, each.Source); err != nil {
+			return &LoadError{Source: each.Name, Line: errorLine(each.Name, err), Err: err}
+		}
+	}
+	if !c.verified {
+		if _, err := d.RuntimeVersion(); err != nil {
+			return err
+		}
+	}
+	// globals defined so far are not saved by SaveGlobals
+	return d.Call("V8D.globals", "mark")
+}
+
+// clone returns a new MessageDispatcher with the same options, handlers, OnClose functions and settings but without user scripts.
+func (d *MessageDispatcher) clone() (*MessageDispatcher, error) {
+	c, err := NewMessageDispatcher(d.options...)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range d.messageHandlerFuncs {
+		c.messageHandlerFuncs[k] = v
+	}
+	for k, v := range d.messageHandlers {
+		// the new dispatcher has its own function references, timers and clock
+		if runtimeReceiver(k) {
+			continue
 		}
+		c.messageHandlers[k] = v
 	}
-	// install default console handling
-	d.RegisterFunc("console.log", ConsoleLog)
-	return d
+	c.closeHooks = append([]func(){}, d.closeHooks...)
+	c.traceEnabled = d.traceEnabled
+	return c, nil
 }
 
 // Worker returns the worker for this dispatcher
@@ -58,7 +172,12 @@ func (d *MessageDispatcher) Worker() *v8worker.Worker {
 	return d.worker
 }
 
-// Trace will cause the internal message sends to be logged. See Log variable.
+// Logger returns the logger this dispatcher uses for its diagnostics, see WithLogger.
+func (d *MessageDispatcher) Logger() *slog.Logger {
+	return d.logger
+}
+
+// Trace will cause the internal message sends to be logged at info level. See WithLogger.
 func (d *MessageDispatcher) Trace(doTrace bool) {
 	d.traceEnabled = doTrace
 }
@@ -75,6 +194,19 @@ func (d *MessageDispatcher) Register(name string, handler MessageSendHandler) {
 	d.messageHandlers[name] = handler
 }
 
+// Handlers returns the sorted names of all registered handlers and handler functions.
+func (d *MessageDispatcher) Handlers() []string {
+	names := []string{}
+	for name := range d.messageHandlers {
+		names = append(names, name)
+	}
+	for name := range d.messageHandlerFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Call is an asynchronous call to Javascript and does no expect a return value
 func (d *MessageDispatcher) Call(receiver string, method string, arguments ...interface{}) error {
 	_, err := d.send(MessageSend{
@@ -98,7 +230,16 @@ func (d *MessageDispatcher) CallReturn(receiver string, method string, arguments
 
 // Callback is an asynchronous call to Javascript that will perform a registered function with optional arguments.
 // The funtionReference must have been created with "V8D.function_registry.put(yourFunction)".
+// A reference can be used once; ErrUnknownReference is returned for references that were not issued or were used.
 func (d *MessageDispatcher) Callback(functionReference string, arguments ...interface{}) error {
+	if d.closed {
+		return ErrClosed
+	}
+	if !d.ids.take(functionReference) {
+		err := fmt.Errorf("%w: %s", ErrUnknownReference, functionReference)
+		d.log(slog.LevelWarn, "callback rejected", MessageSend{Receiver: "V8D", Selector: "callDispatch"}, directionOutbound, slog.Any("err", err))
+		return err
+	}
 	_, err := d.send(MessageSend{
 		Receiver:       "V8D",
 		Selector:       "callDispatch",
@@ -131,80 +272,130 @@ func (d *MessageDispatcher) Get(variableName string) (interface{}, error) {
 
 // ReceiveSync is a v8worker send sync handler.
 func (d *MessageDispatcher) ReceiveSync(jsonFromJS string) string {
-	if d.traceEnabled {
-		Log("trace", "ReceiveSync", "json", jsonFromJS)
-	}
-	var msg MessageSend
-	if err := json.NewDecoder(strings.NewReader(jsonFromJS)).Decode(&msg); err != nil {
-		Log("error", "not a valid MessageSend", "err", err)
-		return err.Error() // TODO
-	}
-	msg.IsAsynchronous = false
-	return d.dispatch(msg)
+	return d.receive(jsonFromJS, false)
 }
 
 // Receive is a v8worker send async handler.
 func (d *MessageDispatcher) Receive(jsonFromJS string) {
+	_ = d.receive(jsonFromJS, true)
+}
+
+// receive decodes and dispatches a MessageSend from Javascript and returns the reply.
+func (d *MessageDispatcher) receive(jsonFromJS string, async bool) string {
 	if d.traceEnabled {
-		Log("trace", "Receive", "json", jsonFromJS)
+		name := "ReceiveSync"
+		if async {
+			name = "Receive"
+		}
+		d.logger.Info(name, "direction", directionInbound, "json", jsonFromJS)
 	}
-	var msg MessageSend
-	if err := json.NewDecoder(strings.NewReader(jsonFromJS)).Decode(&msg); err != nil {
-		Log("error", "not a valid MessageSend", "err", err)
-		return
+	start := time.Now()
+	var in inboundMessage
+	if err := d.limits.check("MaxMessageSize", d.limits.MaxMessageSize, len(jsonFromJS), in.MessageSend, directionInbound); err != nil {
+		d.logger.Error("message rejected", "direction", directionInbound, "err", err)
+		d.observe(in.MessageSend, directionInbound, start, len(jsonFromJS), "", err)
+		return errorReply(err)
 	}
-	msg.IsAsynchronous = true
-	_ = d.dispatch(msg)
+	if err := json.NewDecoder(strings.NewReader(jsonFromJS)).Decode(&in); err != nil {
+		d.logger.Error("not a valid MessageSend", "direction", directionInbound, "err", err)
+		d.observe(in.MessageSend, directionInbound, start, len(jsonFromJS), "", err)
+		return errorReply(err)
+	}
+	if in.Batch != nil {
+		return d.receiveBatch(in.Batch)
+	}
+	msg := in.MessageSend
+	msg.IsAsynchronous = async
+	reply, _ := d.receiveMessage(msg, start, len(jsonFromJS))
+	return reply
 }
 
-// dispatch finds the Go handler registered, calls it and returns the JSON representation of the return value.
-// lookup by "receiver" first then "selector" then "receiver.selector" of the message argument.
-func (d *MessageDispatcher) dispatch(msg MessageSend) string {
+// receiveMessage performs a decoded MessageSend from Javascript and returns the reply and the error, if any.
+// The start and size of the received JSON are used for the metrics.
+func (d *MessageDispatcher) receiveMessage(msg MessageSend, start time.Time, size int) (string, error) {
+	if err := d.enter(msg, directionInbound); err != nil {
+		d.log(slog.LevelError, "message rejected", msg, directionInbound, slog.Any("err", err))
+		d.observe(msg, directionInbound, start, size, "", err)
+		return errorReply(err), err
+	}
+	defer d.leave()
+	span := d.startSpan(msg, directionInbound)
+	reply, err := d.dispatch(msg)
+	d.endSpan(span, err)
+	d.observe(msg, directionInbound, start, size, reply, err)
+	return reply, err
+}
+
+// enter checks the limits of a MessageSend before performing it and increments the call depth.
+// Each successful enter must be followed by a leave.
+func (d *MessageDispatcher) enter(msg MessageSend, direction string) error {
+	if err := d.limits.check("MaxCallDepth", d.limits.MaxCallDepth, d.callDepth+1, msg, direction); err != nil {
+		return err
+	}
+	if direction == directionInbound {
+		if err := d.limits.checkArguments(msg); err != nil {
+			return err
+		}
+	}
+	d.callDepth++
+	return nil
+}
+
+// leave decrements the call depth.
+func (d *MessageDispatcher) leave() {
+	d.callDepth--
+}
+
+// dispatch performs the message, see perform, and returns the JSON representation of the return value.
+func (d *MessageDispatcher) dispatch(msg MessageSend) (string, error) {
 	if d.traceEnabled {
-		Log("trace", "dispatch", "msg", msg)
+		d.log(slog.LevelInfo, "dispatch", msg, directionInbound, slog.Any("args", msg.Arguments))
+	}
+	if err := d.authorize(msg); err != nil {
+		return errorReply(err), err
+	}
+	if err := d.checkQuota(msg); err != nil {
+		return errorReply(err), err
+	}
+	if len(msg.Callback) > 0 && !d.ids.isIssued(msg.Callback) {
+		err := fmt.Errorf("%w: %s", ErrUnknownReference, msg.Callback)
+		d.log(slog.LevelWarn, "callback rejected", msg, directionInbound, slog.Any("err", err))
+		return errorReply(err), err
 	}
 	var result interface{}
 	var err error
-	if len(msg.Receiver) == 0 {
-		performerFunc, ok := d.messageHandlerFuncs[msg.Selector]
-		if !ok {
-			Log("warn", "no handler func", "selector", msg.Selector)
-			return "null"
-		}
-		result, err = performerFunc(msg)
+	start := time.Now()
+	if d.replayer != nil && !runtimeMessage(msg) {
+		result, err = d.replayer.next(msg)
 	} else {
-		performer, ok := d.messageHandlers[msg.Receiver]
-		if !ok {
-			// retry with receiver.selector
-			performerFunc, ok := d.messageHandlerFuncs[fmt.Sprintf("%s.%s", msg.Receiver, msg.Selector)]
-			if !ok {
-				Log("warn", "no handler", "receiver", msg.Receiver, "selector", msg.Selector)
-				return "null"
-			}
-			result, err = performerFunc(msg)
-		} else {
-			result, err = performer.Perform(msg)
-		}
+		result, err = d.perform(msg)
 	}
+	if errors.Is(err, ErrNoHandler) {
+		return "null", err
+	}
+	duration := time.Since(start)
 	if err != nil {
-		Log("error", "perform failed", "err", err.Error())
-		return err.Error() // TODO
+		d.log(slog.LevelError, "perform failed", msg, directionInbound, slog.Duration("duration", duration), slog.Any("err", err))
+		return errorReply(err), err
+	}
+	if d.traceEnabled {
+		d.log(slog.LevelInfo, "performed", msg, directionInbound, slog.Duration("duration", duration))
 	}
 
 	// if no return value is expected and no callback is requested then we are done
 	if msg.IsAsynchronous && len(msg.Callback) == 0 {
-		return ""
+		return "", nil
 	}
 
 	// make the JSON for the result
 	data, err := json.Marshal(result)
 	if err != nil {
-		Log("error", "marshal error", "err", err.Error())
-		return err.Error() // TODO
+		d.log(slog.LevelError, "marshal error", msg, directionInbound, slog.Any("err", err))
+		return errorReply(err), err
 	}
 
 	// if a callback is given then call this first with the result
-	if len(msg.Callback) > 0 {
+	if len(msg.Callback) > 0 && d.ids.take(msg.Callback) {
 		callDispatch := MessageSend{
 			Receiver:       "V8D",
 			Selector:       "callDispatch",
@@ -213,37 +404,140 @@ func (d *MessageDispatcher) dispatch(msg MessageSend) string {
 		}
 		_, err := d.send(callDispatch)
 		if err != nil {
-			Log("error", "callDispatch failed", "err", err.Error())
-			return err.Error() // TODO
+			d.log(slog.LevelError, "callDispatch failed", msg, directionInbound, slog.Any("err", err))
+			return errorReply(err), err
+		}
+	}
+	return string(data), nil
+}
+
+// errorReplyPrefix starts a reply to Javascript that reports an error instead of a JSON value.
+// The runtime throws an Error for such a reply, see V8D.reply.
+const errorReplyPrefix = "V8D.error:"
+
+// workerErrorPrefix starts a reply of the worker for a synchronous call that threw an exception.
+const workerErrorPrefix = "err: "
+
+// errorReply returns the reply to Javascript for an error.
+// The name of the thrown Error is "Error" unless the error has another name, such as LimitExceededError.
+func errorReply(err error) string {
+	name := "Error"
+	var named interface{ errorName() string }
+	if errors.As(err, &named) {
+		name = named.errorName()
+	}
+	data, _ := json.Marshal(map[string]string{"name": name, "message": err.Error()})
+	return errorReplyPrefix + string(data)
+}
+
+// perform calls the Go handler registered for the message and returns its result.
+// lookup by "receiver" first then "selector" then "receiver.selector" of the message argument.
+func (d *MessageDispatcher) perform(msg MessageSend) (interface{}, error) {
+	if len(msg.Receiver) == 0 {
+		performerFunc, ok := d.messageHandlerFuncs[msg.Selector]
+		if !ok {
+			d.log(slog.LevelWarn, "no handler func", msg, directionInbound)
+			return nil, ErrNoHandler
 		}
+		return performerFunc(msg)
 	}
-	return string(data)
+	performer, ok := d.messageHandlers[msg.Receiver]
+	if ok {
+		return performer.Perform(msg)
+	}
+	// retry with receiver.selector
+	performerFunc, ok := d.messageHandlerFuncs[fmt.Sprintf("%s.%s", msg.Receiver, msg.Selector)]
+	if !ok {
+		d.log(slog.LevelWarn, "no handler", msg, directionInbound)
+		return nil, ErrNoHandler
+	}
+	return performerFunc(msg)
 }
 
 // send will perform a MessageSend in Javascript
 // if the message is synchronous then return the result of the Javascript function.
-func (d *MessageDispatcher) send(msg MessageSend) (interface{}, error) {
+func (d *MessageDispatcher) send(msg MessageSend) (value interface{}, err error) {
+	err = d.sendInto(msg, &value)
+	return value, err
+}
+
+// sendInto will perform a MessageSend in Javascript
+// if the message is synchronous then decode the JSON result of the Javascript function into the target.
+func (d *MessageDispatcher) sendInto(msg MessageSend, target interface{}) (err error) {
+	if d.closed {
+		return ErrClosed
+	}
 	if d.traceEnabled {
-		Log("trace", "send", "msg", msg)
+		d.log(slog.LevelInfo, "send", msg, directionOutbound, slog.Any("args", msg.Arguments))
+	}
+	if span := d.startSpan(msg, directionOutbound); span != nil {
+		// pass the span such that messages sent back to Go become its children
+		msg.TraceID, msg.SpanID = span.TraceID, span.SpanID
+		defer func() { d.endSpan(span, err) }()
 	}
+	start := time.Now()
 	callbackJSON, err := msg.JSON()
 	if err != nil {
-		Log("error", "message encode failure", "receiver", msg.Receiver, "method", msg.Selector, "err", err)
-		return nil, err
+		d.log(slog.LevelError, "message encode failure", msg, directionOutbound, slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, 0, "", err)
+		return err
+	}
+	if err := d.limits.check("MaxMessageSize", d.limits.MaxMessageSize, len(callbackJSON), msg, directionOutbound); err != nil {
+		d.log(slog.LevelError, "message rejected", msg, directionOutbound, slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, len(callbackJSON), "", err)
+		return err
+	}
+	if err := d.enter(msg, directionOutbound); err != nil {
+		d.log(slog.LevelError, "message rejected", msg, directionOutbound, slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, len(callbackJSON), "", err)
+		return err
 	}
+	defer d.leave()
 	if msg.IsAsynchronous {
-		if err := d.worker.Send(callbackJSON); err != nil {
-			Log("error", "work send failure", "receiver", msg.Receiver, "method", msg.Selector, "err", err)
-			return nil, err
+		err := d.worker.Send(callbackJSON)
+		d.observe(msg, directionOutbound, start, len(callbackJSON), "", err)
+		if err != nil {
+			d.log(slog.LevelError, "worker send failure", msg, directionOutbound, slog.Duration("duration", time.Since(start)), slog.Any("err", err))
+			return err
+		}
+		if d.traceEnabled {
+			d.log(slog.LevelInfo, "sent", msg, directionOutbound, slog.Duration("duration", time.Since(start)))
 		}
-		return nil, nil
+		return nil
 	}
 	// synchronous
 	reply := d.worker.SendSync(callbackJSON)
-	var value interface{}
-	if err := json.Unmarshal([]byte(reply), &value); err != nil {
-		Log("error", "unmarshal Javascript message failure", "msg", msg, "reply", reply, "err", err)
-		return nil, err
+	duration := time.Since(start)
+	if err := d.limits.check("MaxMessageSize", d.limits.MaxMessageSize, len(reply), msg, directionOutbound); err != nil {
+		d.log(slog.LevelError, "reply rejected", msg, directionOutbound, slog.Duration("duration", duration), slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, len(callbackJSON), "", err)
+		return err
 	}
-	return value, nil
+	// the worker reports an uncaught Javascript exception as "err: " followed by its message
+	if strings.HasPrefix(reply, workerErrorPrefix) {
+		err := fmt.Errorf("%s.%s: %s", msg.Receiver, msg.Selector, strings.TrimPrefix(reply, workerErrorPrefix))
+		d.log(slog.LevelError, "Javascript exception", msg, directionOutbound, slog.Duration("duration", duration), slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, len(callbackJSON), reply, err)
+		return err
+	}
+	if err := json.Unmarshal([]byte(reply), target); err != nil {
+		d.log(slog.LevelError, "unmarshal Javascript message failure", msg, directionOutbound, slog.Duration("duration", duration), slog.String("reply", reply), slog.Any("err", err))
+		d.observe(msg, directionOutbound, start, len(callbackJSON), reply, err)
+		return &ReplyError{Receiver: msg.Receiver, Selector: msg.Selector, Reply: reply, Err: err}
+	}
+	d.observe(msg, directionOutbound, start, len(callbackJSON), reply, nil)
+	if d.traceEnabled {
+		d.log(slog.LevelInfo, "sent", msg, directionOutbound, slog.Duration("duration", duration))
+	}
+	return nil
+}
+
+// log emits a record with the receiver, selector and direction of the message and the additional attributes.
+func (d *MessageDispatcher) log(level slog.Level, text string, msg MessageSend, direction string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.String("receiver", msg.Receiver),
+		slog.String("selector", msg.Selector),
+		slog.String("direction", direction),
+	}, attrs...)
+	d.logger.LogAttrs(context.Background(), level, text, attrs...)
 }
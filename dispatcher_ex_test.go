@@ -3,7 +3,7 @@ package v8dispatcher
 import "fmt"
 
 func ExampleMessageDispatcher_CallReturn() {
-	md := NewMessageDispatcher()
+	md, _ := NewMessageDispatcher()
 	md.Worker().Load("ex.js", `
 		function now() {
 			return new Date();
@@ -1,6 +1,11 @@
 package v8dispatcher
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -26,7 +31,7 @@ var someApiSrc = `
 	`
 
 func TestCallReturn(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	rec := &recorder{}
 	dist.Register("console", rec)
 	dist.Worker().Load("someApi.js", someApiSrc)
@@ -57,7 +62,7 @@ func TestCallReturn(t *testing.T) {
 }
 
 func TestCallThen(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	rec := &recorder{}
 	dist.Register("console", rec)
 	dist.Worker().Load("someApi.js", someApiSrc)
@@ -88,7 +93,7 @@ func TestCallThen(t *testing.T) {
 }
 
 func TestCallThenWithArgument(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	rec := &recorder{}
 	dist.Register("console", rec)
 	dist.Worker().Load("someApi.js", someApiSrc)
@@ -119,7 +124,7 @@ func TestCallThenWithArgument(t *testing.T) {
 }
 
 func TestSetGet(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	rec := &recorder{}
 	dist.Register("console", rec)
 	dist.Set("SomeVar", 42)
@@ -151,7 +156,7 @@ func TestSetGet(t *testing.T) {
 }
 
 func TestSetGetHash(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	rec := &recorder{}
 	dist.Register("console", rec)
 	dist.Set("map", map[string]interface{}{
@@ -175,7 +180,7 @@ func TestSetGetHash(t *testing.T) {
 }
 
 func TestRoundTripWithMap(t *testing.T) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(t)
 	var gotArgument interface{}
 	var gotReturn interface{}
 	dist.RegisterFunc("putBasket", func(msg MessageSend) (interface{}, error) {
@@ -218,7 +223,7 @@ func TestRoundTripWithMap(t *testing.T) {
 }
 
 func BenchmarkRequestFromGo(b *testing.B) {
-	dist := NewMessageDispatcher()
+	dist := newDispatcher(b)
 	worker := dist.Worker()
 	if err := worker.Load("BenchmarkRequestFromGo.js", `
 		function dummy(what) {
@@ -237,3 +242,145 @@ func BenchmarkRequestFromGo(b *testing.B) {
 		worker.SendSync(js)
 	}
 }
+
+// benchmarkBatchSize is the number of MessageSends per iteration of the batch benchmarks.
+const benchmarkBatchSize = 100
+
+func BenchmarkCallsFromGo(b *testing.B) {
+	dist := newDispatcher(b)
+	if err := dist.Worker().Load("BenchmarkCallsFromGo.js", `function dummy(what) { return what; }`); err != nil {
+		b.Fatal(err)
+	}
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < benchmarkBatchSize; i++ {
+			if _, err := dist.CallReturn("this", "dummy", i); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkBatchFromGo(b *testing.B) {
+	dist := newDispatcher(b)
+	if err := dist.Worker().Load("BenchmarkBatchFromGo.js", `function dummy(what) { return what; }`); err != nil {
+		b.Fatal(err)
+	}
+	for n := 0; n < b.N; n++ {
+		if _, err := dist.Batch(func(batch Batcher) {
+			for i := 0; i < benchmarkBatchSize; i++ {
+				batch.Call("this", "dummy", i)
+			}
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCallsFromJS(b *testing.B) {
+	dist := newDispatcher(b)
+	dist.RegisterFunc("dummy", func(msg MessageSend) (interface{}, error) {
+		return msg.Arguments[0], nil
+	})
+	if err := dist.Worker().Load("BenchmarkCallsFromJS.js", `
+		function calls(count) {
+			for (var i = 0; i < count; i++) {
+				V8D.call("", "dummy", i);
+			}
+			return count;
+		}
+	`); err != nil {
+		b.Fatal(err)
+	}
+	for n := 0; n < b.N; n++ {
+		if _, err := dist.CallReturn("this", "calls", benchmarkBatchSize); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBatchFromJS(b *testing.B) {
+	dist := newDispatcher(b)
+	dist.RegisterFunc("dummy", func(msg MessageSend) (interface{}, error) {
+		return msg.Arguments[0], nil
+	})
+	if err := dist.Worker().Load("BenchmarkBatchFromJS.js", `
+		function calls(count) {
+			V8D.batch(function() {
+				for (var i = 0; i < count; i++) {
+					V8D.call("", "dummy", i);
+				}
+			});
+			return count;
+		}
+	`); err != nil {
+		b.Fatal(err)
+	}
+	for n := 0; n < b.N; n++ {
+		if _, err := dist.CallReturn("this", "calls", benchmarkBatchSize); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNoHandlerLoggedWithLog(t *testing.T) {
+	defaultLog := Log
+	defer func() { Log = defaultLog }()
+	logged := []string{}
+	Log = func(level, msg string, kvs ...interface{}) {
+		logged = append(logged, fmt.Sprint(level, " ", msg, " ", kvs))
+	}
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("TestNoHandlerLoggedWithLog.js", `
+		V8D.call("unknown","missing");
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(logged), "[warn no handler [receiver unknown selector missing direction inbound]]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestNoHandlerLogged(t *testing.T) {
+	buf := new(bytes.Buffer)
+	dist := newDispatcher(t, WithLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	if err := dist.Worker().Load("TestNoHandlerLogged.js", `
+		V8D.call("unknown","missing");
+	`); err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]interface{}{
+		"level":     "WARN",
+		"msg":       "no handler",
+		"receiver":  "unknown",
+		"selector":  "missing",
+		"direction": "inbound",
+	} {
+		if got, want := record[k], v; got != want {
+			t.Errorf("%s: got %v want %v", k, got, want)
+		}
+	}
+}
+
+// largeLibrary returns a bootstrap script with many functions.
+func largeLibrary() Script {
+	src := new(strings.Builder)
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(src, "function f%d(x) { var y = x * %d; return [y, 'f%d', {\"n\": y}]; }\n", i, i, i)
+	}
+	return Script{Name: "library.js", Source: src.String()}
+}
+
+func BenchmarkNewMessageDispatcher(b *testing.B) {
+	library := largeLibrary()
+	for n := 0; n < b.N; n++ {
+		d, err := NewMessageDispatcher(WithBootstrapScripts(library))
+		if err != nil {
+			b.Fatal(err)
+		}
+		d.Close()
+	}
+}
@@ -23,6 +23,9 @@ Variables in Javascript can be set and get using:
 	// Set will add/replace the value for a global variable in Javascript.
 	// Get will return the value for the global variable in Javascript.
 
+The generic functions CallReturnAs and GetAs decode the return value into a Go type instead of an interface{}.
+SaveGlobals and RestoreGlobals transfer JSON-serializable global variables between dispatchers, as a GlobalState.
+
 Registration of dispatchers
 
 Dispatching MessageSend values to functions in Go requires the registration of handlers.
@@ -38,7 +41,50 @@ In Go, the receiver field of a MessageSend is used to find a handler (MessageSen
 If found, the handler's Perform method is called with the MessageSend in which the selector can be inspected.
 An empty receiver will cause the dispatcher to look for a registered function (MessageSendHandlerFunc) instead.
 
-A MessageDispatcher has a default function mapped on "console.log" that call the standard log.Println.
+A MessageDispatcher has default functions mapped on "console.log" and the other console selectors
+(debug, info, warn, error, trace, assert, timeEnd, count, group, groupEnd and table) that call the Log function.
+Use the WithConsoleLogger option to log these as log/slog records instead, with the script name and line attached.
+
+Logging
+
+Each MessageDispatcher logs its diagnostics (such as "no handler" and "perform failed") to a log/slog Logger, see WithLogger.
+Records have the attributes receiver, selector, direction ("inbound" for Javascript to Go, "outbound" for Go to Javascript)
+and, when measured, duration. Trace(true) adds records for every MessageSend.
+Without WithLogger, a dispatcher created after replacing the Log function logs its diagnostics using Log.
+
+Use WithMetrics to observe counts, latencies, errors and payload sizes of all MessageSends.
+Use WithTracing to export a Span for each MessageSend; the trace context is passed in the MessageSend
+such that nested calls between Go and Javascript have parent/child relationships.
+
+Use WithLimits to cap the size of MessageSends, the number and nesting of their arguments and the depth of nested calls.
+Violations are reported as a LimitExceededError, thrown in Javascript or returned in Go.
+
+Use WithPolicy to allow Javascript to call only some of the registered handlers; denied calls throw a PolicyError.
+
+Use WithQuota to rate limit or cap the calls to handlers; exceeding calls throw a QuotaExceededError and can be alerted in Go.
+
+V8 startup snapshots are not supported by the v8worker package;
+each dispatcher runs its runtime and bootstrap scripts when it is created.
+
+Recording and replay
+
+Use WithRecording to write all MessageSends, with their replies and timing, to a JSON Lines file.
+A Replayer reads such a recording; with WithReplay, the results of Go handlers are served from the recording
+so that a script run can be reproduced without the Go handlers and their backing services.
+
+Javascript runtime
+
+The scripts in the js folder are embedded in the package and loaded into each new MessageDispatcher.
+Options passed to NewMessageDispatcher can replace this runtime, add bootstrap scripts or leave out the console.
+The runtime exposes its version as V8D.version; RuntimeVersion reports an error if its major version differs from SupportedRuntimeVersion or its minor version is older.
+WithTimers adds setTimeout and setInterval; their functions are called while RunEventLoop runs.
+WithDeterminism seeds Math.random and replaces the clock of Date by a virtual clock that is moved by Advance.
+Eval runs source in the global scope and returns the value of its last expression, or an *EvalError with line and column.
+The v8d command (cmd/v8d) runs a script file with opt-in fs, env and timers modules, or starts a repl.
+
+Close disposes the worker of a MessageDispatcher; functions added with OnClose can release resources of handlers.
+Reset replaces the worker by a new one, with the runtime and bootstrap scripts, and keeps the registered handlers.
+Batch sends several MessageSends to Javascript at once; V8D.batch does the same for calls to Go.
 
 For examples see the README.md and the tests.
 
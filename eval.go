@@ -0,0 +1,82 @@
+package v8dispatcher
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EvalError describes a syntax error or an uncaught exception of an Eval.
+type EvalError struct {
+	// Source is the name passed to Eval.
+	Source string
+	// Name is the name of the Javascript error, such as "SyntaxError" or "ReferenceError"; empty if a non-error was thrown.
+	Name string
+	// Message is the message of the Javascript error or the thrown value.
+	Message string
+	// Line and Column (both starting at 1) locate the error in the source; 0 if unknown.
+	Line, Column int
+	// Stack is the stack trace reported by Javascript, if any.
+	Stack string
+}
+
+func (e *EvalError) Error() string {
+	where := e.Source
+	if e.Line > 0 {
+		where = fmt.Sprintf("%s:%d:%d", e.Source, e.Line, e.Column)
+	}
+	if len(e.Name) == 0 {
+		return fmt.Sprintf("%s: %s", where, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", where, e.Name, e.Message)
+}
+
+// Eval runs the source in the global scope of Javascript and returns the value of its last expression.
+// The name is used in stack traces. Declarations, such as "var x = 1", remain defined for later use.
+// A function value is returned as a string such as "[Function: name]"; undefined is returned as nil.
+// A syntax error or an uncaught exception is returned as an *EvalError.
+func (d *MessageDispatcher) Eval(name, source string) (interface{}, error) {
+	v, err := d.CallReturn("V8D", "eval", name, source)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected eval result: %v", v)
+	}
+	if failure, ok := result["error"].(map[string]interface{}); ok {
+		return nil, newEvalError(name, source, failure)
+	}
+	return result["value"], nil
+}
+
+var (
+	// syntaxPosition matches the position in a syntax error message, e.g. "Line 2:5".
+	syntaxPosition = regexp.MustCompile(`Line (\d+):(\d+)`)
+	// anonymousFrame matches the position in a stack frame of evaluated code without a name.
+	anonymousFrame = regexp.MustCompile(`<(?:eval|anonymous)>:(\d+):(\d+)`)
+)
+
+// newEvalError returns the EvalError for the error object reported by V8D.eval.
+func newEvalError(name, source string, failure map[string]interface{}) *EvalError {
+	e := &EvalError{Source: name}
+	e.Name, _ = failure["name"].(string)
+	e.Message, _ = failure["message"].(string)
+	e.Stack, _ = failure["stack"].(string)
+	namedFrame := regexp.MustCompile(regexp.QuoteMeta(name) + `:(\d+):(\d+)`)
+	for _, each := range [][]string{namedFrame.FindStringSubmatch(e.Stack), anonymousFrame.FindStringSubmatch(e.Stack), syntaxPosition.FindStringSubmatch(e.Message)} {
+		if each != nil {
+			e.Line, _ = strconv.Atoi(each[1])
+			e.Column, _ = strconv.Atoi(each[2])
+			break
+		}
+	}
+	// the source name comment appended by V8D.eval is not part of the source
+	lines := strings.Split(source, "\n")
+	if e.Line > len(lines) {
+		e.Line = len(lines)
+		e.Column = len(lines[e.Line-1]) + 1
+	}
+	return e
+}
@@ -0,0 +1,65 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	dist := newDispatcher(t)
+	if _, err := dist.Eval("decl.js", "var x = 20;"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := dist.Eval("expr.js", "x + 22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, float64(42); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	v, _ = dist.Eval("object.js", "({a: [1, 'b']})")
+	if got, want := v.(map[string]interface{})["a"].([]interface{})[1], "b"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	v, _ = dist.Eval("func.js", "function answer() { return x; }; answer")
+	if got, want := v, "[Function: answer]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	v, _ = dist.Eval("undefined.js", "undefined")
+	if v != nil {
+		t.Errorf("got %v want nil", v)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	dist := newDispatcher(t)
+	for _, each := range []struct {
+		source string
+		name   string
+		line   int
+	}{
+		{"x +", "SyntaxError", 1},
+		{"var a = 1;\n  undefinedFunction();", "ReferenceError", 2},
+		{"\n\nthrow new TypeError('bad')", "TypeError", 3},
+	} {
+		_, err := dist.Eval("failing.js", each.source)
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) {
+			t.Fatalf("%q: EvalError expected, got %v", each.source, err)
+		}
+		if got, want := evalErr.Name, each.name; got != want {
+			t.Errorf("%q: got %v want %v", each.source, got, want)
+		}
+		if got, want := evalErr.Line, each.line; got != want {
+			t.Errorf("%q: got %v want %v", each.source, got, want)
+		}
+		// the exact column differs per engine
+		if evalErr.Column == 0 {
+			t.Errorf("%q: column expected", each.source)
+		}
+	}
+	_, err := dist.Eval("string.js", "throw 'plain'")
+	if got, want := err.Error(), "string.js: plain"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
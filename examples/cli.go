@@ -1,10 +1,14 @@
+//go:build ignore
+
 /*
 
 Command line program that interacts with a V8 Javascript engine through a v8dispatcher.MessageDispather
 
+	go get github.com/GeertJohan/go.linenoise
 	go run cli.go
 
-Currently, every expression entered is wrapped in a console.log(...) call to print the value of that expression.
+Every line entered is evaluated using Eval and the value of its last expression is printed.
+The v8d command (cmd/v8d) has a more complete repl.
 
 Append the "-v" option to see the MessageSends exchanged.
 */
@@ -31,7 +35,12 @@ var (
 func main() {
 	flag.Parse()
 	fmt.Println("V8D is ready")
-	v8d = v8dispatcher.NewMessageDispatcher()
+	var err error
+	v8d, err = v8dispatcher.NewMessageDispatcher()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// override default log
 	v8d.RegisterFunc("console.log",
@@ -46,19 +55,14 @@ func main() {
 }
 
 func processLine(line string) string {
-	if strings.HasPrefix(line, "console.log") {
-		err := v8d.Worker().Load("line0.js", line)
-		if err != nil {
-			return err.Error()
-		}
-		return ""
-	}
-	// wrap expression in a console call to see the result value
-	err := v8d.Worker().Load("line0.js", fmt.Sprintf("console.log(%s);", strings.TrimRight(line, ";")))
+	value, err := v8d.Eval("line0.js", line)
 	if err != nil {
 		return err.Error()
 	}
-	return ""
+	if value == nil {
+		return "undefined"
+	}
+	return fmt.Sprintf("%v", value)
 }
 
 func loop() {
@@ -1,3 +1,5 @@
+//go:build ignore
+
 /*
 
 Example MessageHandler that provides an HTTP api (simple GET only) to Javascript, e.g.
@@ -19,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 
 	v8d "github.com/emicklei/v8dispatcher"
@@ -65,7 +68,10 @@ func (a HttpAPI) Register(m *v8d.MessageDispatcher) {
 }
 
 func main() {
-	m := v8d.NewMessageDispatcher()
+	m, err := v8d.NewMessageDispatcher()
+	if err != nil {
+		log.Fatal(err)
+	}
 	a := HttpAPI{}
 	a.Register(m)
 	m.Worker().Load("myjson-sample.js", `
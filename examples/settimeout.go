@@ -1,3 +1,5 @@
+//go:build ignore
+
 /*
 This example shows how you can use callbacks created in Javascript and called from Go.
 From the Window setTimeout documentation:
@@ -12,13 +14,17 @@ To make this function available in Javascript, a definition is loaded which call
 package main
 
 import (
+	"log"
 	"time"
 
 	v8d "github.com/emicklei/v8dispatcher"
 )
 
 func main() {
-	m := v8d.NewMessageDispatcher()
+	m, err := v8d.NewMessageDispatcher()
+	if err != nil {
+		log.Fatal(err)
+	}
 	m.Trace(true)
 	m.RegisterFunc("setTimeout", func(msg v8d.MessageSend) (interface{}, error) {
 		fnc := msg.Arguments[0].(string)
@@ -0,0 +1,43 @@
+package v8dispatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GlobalState holds the values of global variables of Javascript, see SaveGlobals.
+// It can be persisted as JSON and restored into another MessageDispatcher.
+type GlobalState struct {
+	// Globals maps the names of global variables to their JSON values.
+	Globals map[string]json.RawMessage `json:"globals"`
+}
+
+// SaveGlobals returns the values of the named global variables.
+// Without names, it returns all global variables that are defined after the runtime and bootstrap scripts were loaded
+// and that hold JSON-serializable values; others, such as functions, are skipped.
+// A named variable that is undefined or not serializable is an error.
+// Variables declared with let or const are not global variables.
+func (d *MessageDispatcher) SaveGlobals(names ...string) (*GlobalState, error) {
+	type saved struct {
+		Globals map[string]json.RawMessage `json:"globals"`
+		Skipped []string                   `json:"skipped"`
+	}
+	result, err := CallReturnAs[saved](d, "V8D.globals", "save", names)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > 0 && len(result.Skipped) > 0 {
+		return nil, fmt.Errorf("globals undefined or not serializable: %s", strings.Join(result.Skipped, ","))
+	}
+	if result.Globals == nil {
+		result.Globals = map[string]json.RawMessage{}
+	}
+	return &GlobalState{Globals: result.Globals}, nil
+}
+
+// RestoreGlobals sets the global variables in Javascript to the values of the state.
+func (d *MessageDispatcher) RestoreGlobals(state *GlobalState) error {
+	_, err := d.CallReturn("V8D.globals", "restore", state.Globals)
+	return err
+}
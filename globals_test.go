@@ -0,0 +1,57 @@
+package v8dispatcher
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSaveRestoreGlobals(t *testing.T) {
+	library := Script{Name: "library.js", Source: `var libraryVersion = "1.0";`}
+	dist := newDispatcher(t, WithBootstrapScripts(library))
+	if err := dist.Worker().Load("workflow.js", `
+	var step = 3;
+	var order = {"id": "o-1", "lines": [{"sku": "a", "qty": 2}], "paid": false, "note": null};
+	var handler = function() {};
+	var started = new Date();`); err != nil {
+		t.Fatal(err)
+	}
+	state, err := dist.SaveGlobals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(state.Globals), 2; got != want {
+		t.Fatalf("got %v want %v (%v)", got, want, state.Globals)
+	}
+	// persist
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored GlobalState
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := newDispatcher(t, WithBootstrapScripts(library))
+	if err := fresh.RestoreGlobals(&restored); err != nil {
+		t.Fatal(err)
+	}
+	v, err := fresh.Eval("check.js", `step + ":" + order.lines[0].qty + ":" + order.paid + ":" + typeof handler`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "3:2:false:undefined"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	named, err := dist.SaveGlobals("step", "libraryVersion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(named.Globals["libraryVersion"]), `"1.0"`; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, err := dist.SaveGlobals("handler"); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/emicklei/v8dispatcher
+
+go 1.21
@@ -0,0 +1,112 @@
+package v8dispatcher
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnknownReference is returned by Callback if the function reference was not issued to Javascript
+// or if it was used before.
+var ErrUnknownReference = errors.New("unknown function reference")
+
+// ErrTooManyReferences is thrown in Javascript if a function is put in the registry
+// while maxReferences references are issued and not used.
+var ErrTooManyReferences = errors.New("too many function references")
+
+// maxReferences is the number of issued references that are not used by a callback, such that
+// a script cannot grow the memory of Go by putting functions in the registry that are never called back.
+const maxReferences = 100000
+
+// idAllocator is the MessageSendHandler for "V8D.ids" that issues the references of functions in the registry
+// (see js/registry.js) and the values of V8D.uuid.
+// A reference is a sequence number followed by random bytes such that it is unique and cannot be guessed.
+type idAllocator struct {
+	mutex    sync.Mutex
+	sequence uint64
+	random   io.Reader
+	issued   map[string]bool
+	max      int
+}
+
+func newIDAllocator(random io.Reader) *idAllocator {
+	return &idAllocator{random: random, issued: map[string]bool{}, max: maxReferences}
+}
+
+// Perform is part of MessageSendHandler.
+func (a *idAllocator) Perform(msg MessageSend) (interface{}, error) {
+	switch msg.Selector {
+	case "next":
+		return a.next()
+	case "uuid":
+		return a.uuid()
+	default:
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+}
+
+// next issues a new reference; ErrTooManyReferences if the maximum of unused references is issued.
+func (a *idAllocator) next() (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.issued) >= a.max {
+		return "", ErrTooManyReferences
+	}
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(a.random, b); err != nil {
+		return "", err
+	}
+	a.sequence++
+	id := fmt.Sprintf("%d-%x", a.sequence, b)
+	a.issued[id] = true
+	return id, nil
+}
+
+// uuid returns a random (version 4) UUID.
+func (a *idAllocator) uuid() (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(a.random, b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// isIssued returns whether the reference was issued and not taken.
+func (a *idAllocator) isIssued(id string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.issued[id]
+}
+
+// take returns whether the reference was issued and not taken, and forgets it.
+func (a *idAllocator) take(id string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	ok := a.issued[id]
+	delete(a.issued, id)
+	return ok
+}
+
+// reset forgets all issued references and continues with another source of random bytes.
+func (a *idAllocator) reset(random io.Reader) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.random = random
+	a.issued = map[string]bool{}
+}
+
+// clear forgets all issued references.
+func (a *idAllocator) clear() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.issued = map[string]bool{}
+}
+
+// defaultRandom is the source of references unless WithDeterminism is used.
+var defaultRandom io.Reader = rand.Reader
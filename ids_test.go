@@ -0,0 +1,108 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestCallbackReferences(t *testing.T) {
+	dist := newDispatcher(t)
+	var ref string
+	dist.RegisterFunc("later", func(m MessageSend) (interface{}, error) {
+		ref = m.Arguments[0].(string)
+		return nil, nil
+	})
+	if err := dist.Worker().Load("callback.js", `
+	var called = 0;
+	V8D.call("", "later", V8D.function_registry.put(function() { called++; }));`); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^1-[0-9a-f]{16}$`).MatchString(ref) {
+		t.Errorf("unexpected reference %q", ref)
+	}
+	if err := dist.Callback(ref); err != nil {
+		t.Fatal(err)
+	}
+	if err := dist.Callback(ref); !errors.Is(err, ErrUnknownReference) {
+		t.Errorf("ErrUnknownReference expected for used reference, got %v", err)
+	}
+	if err := dist.Callback("1-0000000000000000"); !errors.Is(err, ErrUnknownReference) {
+		t.Errorf("ErrUnknownReference expected for forged reference, got %v", err)
+	}
+	called, err := GetAs[int](dist, "called")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := called, 1; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestForgedCallThen(t *testing.T) {
+	dist := newDispatcher(t)
+	dist.RegisterFunc("now", func(m MessageSend) (interface{}, error) {
+		return "monday", nil
+	})
+	_, err := dist.Eval("forged.js", `
+	V8D.function_registry.table["forged"] = function() {};
+	V8D.reply($sendSync(JSON.stringify({"receiver": "", "selector": "now", "callback": "forged", "args": []})))`)
+	var evalErr *EvalError
+	if !errors.As(err, &evalErr) {
+		t.Fatalf("EvalError expected, got %v", err)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	dist := newDispatcher(t)
+	uuids, err := dist.Eval("uuid.js", `[V8D.uuid(), V8D.uuid()]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := uuids.([]interface{})
+	if pair[0] == pair[1] {
+		t.Error("different uuids expected")
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(pair[0].(string)) {
+		t.Errorf("unexpected uuid %v", pair[0])
+	}
+}
+
+func TestTooManyReferences(t *testing.T) {
+	dist := newDispatcher(t)
+	dist.ids.max = 3
+	var refs []string
+	dist.RegisterFunc("later", func(m MessageSend) (interface{}, error) {
+		refs = append(refs, m.Arguments[0].(string))
+		return nil, nil
+	})
+	v, err := dist.Eval("leak.js", `
+	var error = "";
+	try {
+		for (var i = 0; i < 10; i++) {
+			V8D.call("", "later", V8D.function_registry.put(function() {}));
+		}
+	} catch (e) {
+		error = e.message;
+	}
+	error`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, ErrTooManyReferences.Error(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(refs), 3; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	// a used reference makes room for a new one
+	if err := dist.Callback(refs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dist.Eval("again.js", `V8D.function_registry.put(function() {})`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dist.Eval("full.js", `V8D.function_registry.put(function() {})`); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -15,17 +15,124 @@ console.print = function() {
     $print(msg)
 }
 
+// caller returns the script name and line of the first stack frame outside this file.
+//
+console.caller = function() {
+    var frames = (new Error().stack || "").split("\n");
+    for (var i = 1; i < frames.length; i++) {
+        var match = /([^\s()]+):(\d+):\d+/.exec(frames[i]);
+        if (match != null && match[1] != "console.js") {
+            return {"source": match[1], "line": parseInt(match[2], 10)};
+        }
+    }
+    return {};
+}
+
+// send performs the console selector in Go with the arguments and the location of the caller.
+//
+console.send = function(selector, args) {
+    var caller = console.caller();
+    $send(JSON.stringify(V8D.envelope({
+        "receiver": "console",
+        "selector": selector,
+        "args": args,
+        "source": caller.source,
+        "line": caller.line
+    })));
+}
+
 // log takes a variable number of arguments
 //
 console.log = function( /* arguments */ ) {
-    var args = [];
-    // flatten all arguments	
-    for (var i = 0; i < arguments.length; i++) {
-        args.push(arguments[i]);
+    console.send("log", [].slice.call(arguments));
+}
+
+// debug, info, warn and error take a variable number of arguments and are logged at that level.
+//
+console.debug = function( /* arguments */ ) {
+    console.send("debug", [].slice.call(arguments));
+}
+console.info = function( /* arguments */ ) {
+    console.send("info", [].slice.call(arguments));
+}
+console.warn = function( /* arguments */ ) {
+    console.send("warn", [].slice.call(arguments));
+}
+console.error = function( /* arguments */ ) {
+    console.send("error", [].slice.call(arguments));
+}
+
+// trace takes a variable number of arguments; the stack trace is sent as the last argument.
+//
+console.trace = function( /* arguments */ ) {
+    var args = [].slice.call(arguments);
+    var frames = (new Error().stack || "").split("\n").slice(2);
+    args.push(frames.join("\n"));
+    console.send("trace", args);
+}
+
+// assert sends the arguments as an error if the condition is falsy.
+//
+console.assert = function(condition /*, arguments */ ) {
+    if (condition) {
+        return;
     }
-    $send(JSON.stringify({
-        "receiver": "console",
-        "selector": "log",
-        "args": args
-    }));
+    console.send("assert", ["Assertion failed"].concat([].slice.call(arguments, 1)));
+}
+
+// timers keeps the start times by label.
+//
+console.timers = {};
+
+// time starts a timer with a label.
+//
+console.time = function(label) {
+    console.timers[label || "default"] = Date.now();
+}
+
+// timeEnd stops the timer with a label and sends the label and elapsed milliseconds.
+//
+console.timeEnd = function(label) {
+    label = label || "default";
+    var start = console.timers[label];
+    if (start === undefined) {
+        console.send("warn", ["Timer '" + label + "' does not exist"]);
+        return;
+    }
+    delete console.timers[label];
+    console.send("timeEnd", [label, Date.now() - start]);
+}
+
+// counters keeps the counts by label.
+//
+console.counters = {};
+
+// count increments the counter with a label and sends the label and the count.
+//
+console.count = function(label) {
+    label = label || "default";
+    console.counters[label] = (console.counters[label] || 0) + 1;
+    console.send("count", [label, console.counters[label]]);
+}
+
+// group sends the start of a group of messages with optional arguments as its label.
+//
+console.group = function( /* arguments */ ) {
+    console.send("group", [].slice.call(arguments));
+}
+
+// groupEnd sends the end of the current group.
+//
+console.groupEnd = function() {
+    console.send("groupEnd", []);
+}
+
+// table sends the tabular data and the optional columns to show.
+//
+console.table = function(data, columns) {
+    var args = [data];
+    if (columns !== undefined) {
+        args.push(columns);
+    }
+    console.send("table", args);
 }
@@ -0,0 +1,52 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// clock gives the time of the virtual clock in Go, in milliseconds since the epoch.
+//
+V8D.clock = {};
+
+V8D.clock.now = function() {
+    // never part of a batch
+    return V8D.sendSync({"receiver": "V8D.clock", "selector": "now", "args": []});
+}
+
+// random is a seeded pseudo random generator (mulberry32) that replaces Math.random.
+//
+V8D.random = {"state": 0};
+
+V8D.random.seed = function(seed) {
+    V8D.random.state = seed >>> 0;
+}
+
+Math.random = function() {
+    var t = V8D.random.state = (V8D.random.state + 0x6D2B79F5) >>> 0;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+}
+
+// Date is replaced such that "new Date()" and "Date.now()" use the virtual clock.
+// Dates created from arguments are not affected.
+//
+Date = (function(RealDate) {
+    function VirtualDate() {
+        if (!(this instanceof VirtualDate)) {
+            return new RealDate(V8D.clock.now()).toString();
+        }
+        if (arguments.length == 0) {
+            return new RealDate(V8D.clock.now());
+        }
+        return new(Function.prototype.bind.apply(RealDate, [null].concat([].slice.call(arguments))));
+    }
+    VirtualDate.prototype = RealDate.prototype;
+    VirtualDate.UTC = RealDate.UTC;
+    VirtualDate.parse = RealDate.parse;
+    VirtualDate.now = function() {
+        return V8D.clock.now();
+    }
+    return VirtualDate;
+})(Date);
@@ -7,29 +7,32 @@
 
 var V8D = V8D || {"outerThis":this};
 
-// http://stackoverflow.com/questions/105034/create-guid-uuid-in-javascript
+// version of this runtime; the Go side checks that its major version is supported and its minor version is not older.
+V8D.version = "1.6.0";
+
+// uuid returns a random (version 4) UUID generated by Go.
+//
 V8D.uuid = function() {
-    return 'xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx'.replace(/[xy]/g, function(c) {
-        var r = Math.random() * 16 | 0,
-            v = c == 'x' ? r : (r & 0x3 | 0x8);
-        return v.toString(16);
-    });
+    // never part of a batch
+    return V8D.sendSync({"receiver": "V8D.ids", "selector": "uuid", "args": []});
 }
 
-// function_registry keeps identifyable (by generated id) functions
+// function_registry keeps functions by a reference that is issued by Go.
+// Go only accepts callbacks for issued references.
 //
-V8D.function_registry = {};
+V8D.function_registry = {"table": {}};
 V8D.function_registry.none = undefined;
 V8D.function_registry.put = function(func) {
-    var ref = V8D.uuid();
-    this[ref] = func;
+    // not callReturn, which would send the messages of a batch
+    var ref = V8D.sendSync({"receiver": "V8D.ids", "selector": "next", "args": []});
+    this.table[ref] = func;
     return ref;
 }
 
 // take returns the function by its reference and removes it from the registry.
 //
 V8D.function_registry.take = function(ref) {
-    var func = this[ref];
-    this[ref] = undefined;
+    var func = this.table[ref];
+    delete this.table[ref];
     return func;
 }
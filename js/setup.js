@@ -7,24 +7,54 @@
 
 V8D.receiveCallback = function(msg) {
     var obj = JSON.parse(msg);
-    var context = this;
-    if (obj.receiver != "this") {
-        var namespaces = obj.receiver.split(".");
+    // messages sent to Go while performing this one are part of its span
+    var outerSpan = V8D.currentSpan;
+    if (obj.trace) {
+        V8D.currentSpan = {"trace": obj.trace, "span": obj.span};
+    }
+    try {
+        var context = V8D.resolve(obj.receiver);
+        var func = context[obj.selector];
+        if (func != null) {
+            return JSON.stringify(func.apply(context, obj.args));
+        } else {
+            // try reporting the error
+            if (typeof console !== "undefined") {
+                console.log("[JS] unable to perform", msg);
+            }
+            // TODO return error?
+            return "null";
+        }
+    } finally {
+        V8D.currentSpan = outerSpan;
+    }
+}
+
+// resolve returns the object for a receiver such as "this", "V8D" or "app.store".
+//
+V8D.resolve = function(receiver) {
+    var context = V8D.outerThis;
+    if (receiver != "this") {
+        var namespaces = receiver.split(".");
         for (var i = 0; i < namespaces.length; i++) {
             context = context[namespaces[i]];
         }
     }
-    var func = context[obj.selector];
-    if (func != null) {
-        return JSON.stringify(func.apply(context, obj.args));
-    } else {
-        // try reporting the error
-        if (console != null) {
-            console.log("[JS] unable to perform", msg);
-        }
-        // TODO return error?
-        return "null";
+    return context;
+}
+
+// currentSpan holds the trace and span of the message from Go that is being performed, if traced.
+//
+V8D.currentSpan = undefined;
+
+// envelope adds the current trace context, if any, to a message for Go.
+//
+V8D.envelope = function(msg) {
+    if (V8D.currentSpan !== undefined) {
+        msg.trace = V8D.currentSpan.trace;
+        msg.span = V8D.currentSpan.span;
     }
+    return msg;
 }
 
 // This callback is set for handling function calls from Go transferred as JSON.
@@ -79,7 +109,32 @@ V8D.callReturn = function(receiver, selector /*, arguments */ ) {
         "selector": selector,
         "args": [].slice.call(arguments).splice(2)
     };
-    return JSON.parse($sendSync(JSON.stringify(msg)));
+    // messages of a batch are sent before this one
+    V8D.flushBatch();
+    return V8D.sendSync(msg);
+}
+
+// sendSync sends a MessageSend to Go and returns the value of the reply; it is never batched.
+//
+V8D.sendSync = function(msg) {
+    return V8D.reply($sendSync(JSON.stringify(V8D.envelope(msg))));
+}
+
+// errorPrefix starts a reply from Go that reports an error instead of a JSON value.
+//
+V8D.errorPrefix = "V8D.error:";
+
+// reply returns the value of a reply from Go or throws an Error if Go reports an error.
+// The name of the Error is set by Go, e.g. "LimitExceededError".
+//
+V8D.reply = function(reply) {
+    if (typeof reply === "string" && reply.lastIndexOf(V8D.errorPrefix, 0) === 0) {
+        var info = JSON.parse(reply.substring(V8D.errorPrefix.length));
+        var error = new Error(info.message);
+        error.name = info.name;
+        throw error;
+    }
+    return JSON.parse(reply);
 }
 
 // call performs a MessageSend in Go and does NOT return a value.
@@ -90,7 +145,7 @@ V8D.call = function(receiver, selector /*, arguments */ ) {
         "selector": selector,
         "args": [].slice.call(arguments).splice(2)
     };
-    $send(JSON.stringify(msg));
+    V8D.send(msg);
 }
 
 // callThen performs a MessageSend in Go which can call the onReturn function.
@@ -103,7 +158,84 @@ V8D.callThen = function(receiver, selector, onReturnFunction /*, arguments */ )
         "callback": V8D.function_registry.put(onReturnFunction),
         "args": [].slice.call(arguments).splice(3)
     };
-    $send(JSON.stringify(msg));
+    V8D.send(msg);
+}
+
+// send sends a MessageSend to Go, or adds it to the current batch, if any.
+//
+V8D.send = function(msg) {
+    if (V8D.batching !== undefined) {
+        V8D.batching.push(V8D.envelope(msg));
+        return;
+    }
+    $send(JSON.stringify(V8D.envelope(msg)));
+}
+
+// batching holds the messages of the current batch; undefined if not batching.
+//
+V8D.batching = undefined;
+
+// batch calls the function and sends all messages of V8D.call and V8D.callThen made by it to Go at once.
+// It returns the results of these messages, in order, each either {"value": ...} or {"error": {"name": ..., "message": ...}}.
+// A callReturn inside the function first sends the messages collected so far; their results are included.
+// Messages of the runtime, such as those of setTimeout, are not batched.
+// If the function throws, the collected messages that are not yet sent are discarded.
+// A batch inside a batch is part of the outer batch and returns undefined.
+//
+V8D.batch = function(func) {
+    if (V8D.batching !== undefined) {
+        func();
+        return undefined;
+    }
+    V8D.batching = [];
+    V8D.batchResults = [];
+    try {
+        func();
+        V8D.flushBatch();
+        return V8D.batchResults;
+    } finally {
+        V8D.batching = undefined;
+        V8D.batchResults = undefined;
+    }
+}
+
+// flushBatch sends the messages collected by the current batch, if any, and keeps their results.
+//
+V8D.flushBatch = function() {
+    if (V8D.batching === undefined || V8D.batching.length == 0) {
+        return;
+    }
+    var messages = V8D.batching;
+    V8D.batching = [];
+    var msg = {
+        "receiver": "V8D",
+        "selector": "batch",
+        "batch": messages
+    };
+    var results = V8D.sendSync(msg);
+    V8D.batchResults.push.apply(V8D.batchResults, results);
+}
+
+// performBatch is used from Go to perform a batch of messages and returns their results, in order,
+// each either {"value": ...} or {"error": {"name": ..., "message": ...}}.
+//
+V8D.performBatch = function(messages) {
+    return messages.map(function(each) {
+        try {
+            var context = V8D.resolve(each.receiver);
+            var func = context == null ? null : context[each.selector];
+            if (typeof func !== "function") {
+                return {"error": {"name": "TypeError", "message": "unable to perform " + each.receiver + "." + each.selector}};
+            }
+            var value = func.apply(context, each.args);
+            return {"value": value === undefined ? null : value};
+        } catch (e) {
+            if (e instanceof Error) {
+                return {"error": {"name": e.name, "message": e.message}};
+            }
+            return {"error": {"name": "", "message": String(e)}};
+        }
+    });
 }
 
 // set adds/replaces the value for a variable in the global scope.
@@ -116,4 +248,94 @@ V8D.set = function(variableName,itsValue) {
 //
 V8D.get = function(variableName) {
 	return V8D.outerThis[variableName];
-}
\ No newline at end of file
+}
+
+// globals saves and restores JSON-serializable global variables.
+// The baseline holds the names of the globals of the runtime and bootstrap scripts, see mark.
+//
+V8D.globals = {"baseline": {}};
+
+// mark records the names of all current globals as the baseline.
+//
+V8D.globals.mark = function() {
+    Object.getOwnPropertyNames(V8D.outerThis).forEach(function(name) {
+        V8D.globals.baseline[name] = true;
+    });
+}
+
+// serializable returns whether the value can be restored from its JSON representation.
+//
+V8D.globals.serializable = function(value) {
+    if (typeof value === "function" || value === undefined) {
+        return false;
+    }
+    if (value === null || typeof value !== "object") {
+        return true;
+    }
+    if (!Array.isArray(value) && Object.getPrototypeOf(value) !== Object.prototype) {
+        return false;
+    }
+    for (var key in value) {
+        if (!V8D.globals.serializable(value[key])) {
+            return false;
+        }
+    }
+    return true;
+}
+
+// save returns the values of the named globals, or of all globals not in the baseline,
+// and the names of the globals that are not serializable.
+//
+V8D.globals.save = function(names) {
+    var result = {"globals": {}, "skipped": []};
+    if (names === null || names.length == 0) {
+        names = Object.getOwnPropertyNames(V8D.outerThis).filter(function(name) {
+            return !V8D.globals.baseline[name];
+        });
+    }
+    names.forEach(function(name) {
+        var value = V8D.outerThis[name];
+        var ok = false;
+        try {
+            ok = V8D.globals.serializable(value);
+        } catch (e) {
+            // too deep or cyclic
+        }
+        if (ok) {
+            result.globals[name] = value;
+        } else {
+            result.skipped.push(name);
+        }
+    });
+    return result;
+}
+
+// restore sets the globals to the values.
+//
+V8D.globals.restore = function(globals) {
+    for (var name in globals) {
+        V8D.outerThis[name] = globals[name];
+    }
+    return null;
+}
+
+// eval runs the source in the global scope and returns an object with the value of its last expression.
+// A function value is returned as a string; an exception is returned as the error of the object.
+//
+V8D.eval = function(name, source) {
+    try {
+        var value = (0, eval)(source + "\n//# sourceURL=" + name);
+        if (typeof value === "function") {
+            value = "[Function: " + (value.name || "anonymous") + "]";
+        }
+        return {"value": value};
+    } catch (e) {
+        return {"error": {"name": e.name, "message": String(e.message === undefined ? e : e.message), "stack": e.stack}};
+    }
+}
+
+// runtimeVersion returns the version of this runtime.
+//
+V8D.runtimeVersion = function() {
+	return V8D.version;
+}
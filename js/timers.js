@@ -0,0 +1,65 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.txt', which is part of this source code package.
+ *
+ * author: emicklei
+ */
+
+// timers keeps the functions of setTimeout and setInterval by id.
+// Go schedules the timers and fires them using V8D.timers.fire while running the event loop.
+//
+V8D.timers = {"next": 1, "table": {}};
+
+// add registers a timer function and schedules it in Go; returns the timer id.
+//
+V8D.timers.add = function(func, ms, repeat, args) {
+    var id = V8D.timers.next++;
+    V8D.timers.table[id] = {"func": func, "args": args, "repeat": repeat};
+    V8D.timers.send("start", [id, ms || 0, repeat]);
+    return id;
+}
+
+// fire calls the function of a timer; a timeout is removed first.
+//
+V8D.timers.fire = function(id) {
+    var timer = V8D.timers.table[id];
+    if (timer === undefined) {
+        return;
+    }
+    if (!timer.repeat) {
+        delete V8D.timers.table[id];
+    }
+    timer.func.apply(V8D.outerThis, timer.args);
+}
+
+// clear removes a timer and unschedules it in Go.
+//
+V8D.timers.clear = function(id) {
+    if (V8D.timers.table[id] === undefined) {
+        return;
+    }
+    delete V8D.timers.table[id];
+    V8D.timers.send("stop", [id]);
+}
+
+// send performs a MessageSend in the timers of Go; it is never part of a batch.
+//
+V8D.timers.send = function(selector, args) {
+    $send(JSON.stringify(V8D.envelope({"receiver": "V8D.timers", "selector": selector, "args": args})));
+}
+
+setTimeout = function(func, ms /*, arguments */ ) {
+    return V8D.timers.add(func, ms, false, [].slice.call(arguments, 2));
+}
+
+setInterval = function(func, ms /*, arguments */ ) {
+    return V8D.timers.add(func, ms, true, [].slice.call(arguments, 2));
+}
+
+clearTimeout = function(id) {
+    V8D.timers.clear(id);
+}
+
+clearInterval = function(id) {
+    V8D.timers.clear(id);
+}
@@ -0,0 +1,59 @@
+package v8dispatcher
+
+import "errors"
+
+// ErrClosed is returned by the methods of a MessageDispatcher that was closed.
+var ErrClosed = errors.New("dispatcher is closed")
+
+// OnClose adds a function that is called when the dispatcher is closed, e.g. to release resources of a handler.
+// Functions are called in reverse order of adding.
+func (d *MessageDispatcher) OnClose(hook func()) {
+	d.closeHooks = append(d.closeHooks, hook)
+}
+
+// Close stops all timers, rejects all issued function references, calls the OnClose functions,
+// unregisters all handlers and disposes the worker and its V8 isolate.
+// After Close, calls to Javascript return ErrClosed and the Worker must not be used.
+// Close must not be called while the dispatcher is used by another goroutine; closing more than once has no effect.
+func (d *MessageDispatcher) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	if d.timers != nil {
+		d.timers.clear()
+	}
+	d.ids.clear()
+	for i := len(d.closeHooks) - 1; i >= 0; i-- {
+		d.closeHooks[i]()
+	}
+	d.closeHooks = nil
+	d.messageHandlerFuncs = map[string]MessageSendHandlerFunc{}
+	d.messageHandlers = map[string]MessageSendHandler{}
+	d.worker.Dispose()
+	return nil
+}
+
+// Closed returns whether Close was called.
+func (d *MessageDispatcher) Closed() bool {
+	return d.closed
+}
+
+// Reset replaces the worker by a new one with the runtime and bootstrap scripts, as if the dispatcher was just created.
+// All Javascript state, such as user globals and functions in the registry, and all timers, issued function references,
+// quota usage and scripts loaded by LoadFS and LoadDir are discarded.
+// Registered handlers, OnClose functions and settings such as Trace are kept.
+// Reset must not be called by a handler; if Reset fails, the dispatcher should be closed.
+func (d *MessageDispatcher) Reset() error {
+	if d.closed {
+		return ErrClosed
+	}
+	previous := d.worker
+	c := newConfig(d.options)
+	// the runtime was checked when the dispatcher was created
+	c.verified = true
+	err := d.start(c)
+	previous.Dispose()
+	d.scriptSources = nil
+	return err
+}
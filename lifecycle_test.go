@@ -0,0 +1,123 @@
+package v8dispatcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClose(t *testing.T) {
+	dist := newDispatcher(t, WithTimers())
+	var ref string
+	dist.RegisterFunc("later", func(m MessageSend) (interface{}, error) {
+		ref = m.Arguments[0].(string)
+		return nil, nil
+	})
+	if err := dist.Worker().Load("pending.js", `
+	setTimeout(function() {}, 1000);
+	V8D.call("", "later", V8D.function_registry.put(function() {}));`); err != nil {
+		t.Fatal(err)
+	}
+	closed := []string{}
+	dist.OnClose(func() { closed = append(closed, "first") })
+	dist.OnClose(func() { closed = append(closed, "second") })
+
+	if err := dist.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dist.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(closed), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := closed[0], "second"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := dist.PendingTimers(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(dist.Handlers()), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if !dist.Closed() {
+		t.Error("closed expected")
+	}
+	for i, err := range []error{
+		dist.Callback(ref),
+		dist.Call("this", "f"),
+		dist.Set("x", 1),
+		dist.LoadDir(t.TempDir()),
+		dist.RunEventLoop(context.Background()),
+	} {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("%d: ErrClosed expected, got %v", i, err)
+		}
+	}
+	if _, err := dist.Eval("closed.js", "1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("ErrClosed expected, got %v", err)
+	}
+}
+
+func TestResetNoLeaks(t *testing.T) {
+	library := Script{Name: "library.js", Source: `var library = {"name": "shared"};`}
+	dist := newDispatcher(t, WithTimers(), WithBootstrapScripts(library), WithQuota("", "store", Quota{Total: 1}))
+	stored := []interface{}{}
+	dist.RegisterFunc("store", func(m MessageSend) (interface{}, error) {
+		stored = append(stored, m.Arguments[0])
+		return nil, nil
+	})
+	tenant := func(secret string) (string, error) {
+		// the script of a tenant reads what it can find of others, then leaves its own traces
+		if err := dist.Worker().Load("tenant.js", `
+		var found = [typeof secret, typeof Object.prototype.polluted, typeof V8D.stash, library.name, typeof timerRan].join(",");
+		var secret = "`+secret+`";
+		Object.prototype.polluted = secret;
+		V8D.stash = secret;
+		library.name = secret;
+		setTimeout(function() { timerRan = true; }, 1000);
+		V8D.call("", "store", V8D.function_registry.put(function() {}));`); err != nil {
+			return "", err
+		}
+		return GetAs[string](dist, "found")
+	}
+	found, err := tenant("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean := "undefined,undefined,undefined,shared,undefined"
+	if got, want := found, clean; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := dist.Set("fromGo", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dist.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dist.PendingTimers(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := dist.Callback(stored[0].(string)); !errors.Is(err, ErrUnknownReference) {
+		t.Errorf("ErrUnknownReference expected, got %v", err)
+	}
+	found, err = tenant("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := found, clean; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	fromGo, err := dist.Eval("fromGo.js", "typeof fromGo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fromGo, "undefined"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// the handler is kept and the quota usage is reset
+	if got, want := len(stored), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package v8dispatcher
+
+import "fmt"
+
+// Limits caps the MessageSends exchanged between Go and Javascript; a zero field means no limit.
+//
+// The heap size of the V8 isolate cannot be limited;
+// the v8worker package does not expose the resource constraints of V8.
+type Limits struct {
+	// MaxMessageSize is the maximum number of bytes of the JSON of a MessageSend or a reply, in both directions.
+	MaxMessageSize int
+	// MaxArguments is the maximum number of arguments of a MessageSend from Javascript.
+	MaxArguments int
+	// MaxDepth is the maximum nesting of arrays and objects in an argument of a MessageSend from Javascript.
+	MaxDepth int
+	// MaxCallDepth is the maximum number of nested MessageSends, such as a Go handler calling Javascript
+	// that calls Go again.
+	MaxCallDepth int
+}
+
+// WithLimits sets the limits of MessageSends.
+// A MessageSend from Javascript that exceeds a limit is not performed; a synchronous call throws a
+// LimitExceededError in Javascript. A MessageSend from Go that exceeds a limit is not sent and
+// its call returns a *LimitExceededError.
+func WithLimits(limits Limits) Option {
+	return func(c *config) {
+		c.limits = limits
+	}
+}
+
+// LimitExceededError is returned or thrown if a MessageSend exceeds one of the Limits.
+type LimitExceededError struct {
+	// Limit is the name of the field in Limits.
+	Limit string
+	// Max is the value of the limit; Actual is the value that exceeded it.
+	Max, Actual int
+	// Receiver and Selector identify the MessageSend, if known.
+	Receiver, Selector string
+	// Direction is "inbound" for Javascript to Go, "outbound" for Go to Javascript.
+	Direction string
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s %d > %d (%s %s.%s)", e.Limit, e.Actual, e.Max, e.Direction, e.Receiver, e.Selector)
+}
+
+// errorName is the name of the Error thrown in Javascript.
+func (e *LimitExceededError) errorName() string { return "LimitExceededError" }
+
+// check returns a LimitExceededError if the actual value exceeds a non-zero maximum.
+func (l Limits) check(limit string, max, actual int, msg MessageSend, direction string) error {
+	if max == 0 || actual <= max {
+		return nil
+	}
+	return &LimitExceededError{Limit: limit, Max: max, Actual: actual, Receiver: msg.Receiver, Selector: msg.Selector, Direction: direction}
+}
+
+// checkArguments checks the number and the nesting of the arguments of a MessageSend from Javascript.
+func (l Limits) checkArguments(msg MessageSend) error {
+	if err := l.check("MaxArguments", l.MaxArguments, len(msg.Arguments), msg, directionInbound); err != nil {
+		return err
+	}
+	if l.MaxDepth == 0 {
+		return nil
+	}
+	for _, each := range msg.Arguments {
+		if err := l.check("MaxDepth", l.MaxDepth, valueDepth(each), msg, directionInbound); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueDepth returns the nesting of arrays and objects of a decoded JSON value; 0 for other values.
+func valueDepth(v interface{}) int {
+	depth := 0
+	switch value := v.(type) {
+	case []interface{}:
+		for _, each := range value {
+			depth = max(depth, valueDepth(each))
+		}
+	case map[string]interface{}:
+		for _, each := range value {
+			depth = max(depth, valueDepth(each))
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
@@ -0,0 +1,73 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimitsInbound(t *testing.T) {
+	dist := newDispatcher(t, WithLimits(Limits{MaxMessageSize: 200, MaxArguments: 2, MaxDepth: 2}))
+	performed := 0
+	dist.RegisterFunc("echo", func(m MessageSend) (interface{}, error) {
+		performed++
+		return m.Arguments, nil
+	})
+	for _, each := range []struct {
+		call  string
+		limit string
+	}{
+		{`V8D.callReturn("", "echo", 1, [[2]])`, ""},
+		{`V8D.callReturn("", "echo", 1, 2, 3)`, "MaxArguments"},
+		{`V8D.callReturn("", "echo", [[[3]]])`, "MaxDepth"},
+		{`V8D.callReturn("", "echo", new Array(200).join("x"))`, "MaxMessageSize"},
+	} {
+		if err := dist.Worker().Load("limits.js", `
+		function attempt() { try { `+each.call+`; return ""; } catch (e) { return e.name + ":" + e.message; } }`); err != nil {
+			t.Fatal(err)
+		}
+		v, err := dist.CallReturn("this", "attempt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := v.(string)
+		if len(each.limit) == 0 {
+			if len(text) > 0 {
+				t.Errorf("%s: unexpected %s", each.call, text)
+			}
+			continue
+		}
+		if !strings.HasPrefix(text, "LimitExceededError:") || !strings.Contains(text, each.limit) {
+			t.Errorf("%s: got %q", each.call, text)
+		}
+	}
+	if got, want := performed, 1; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestLimitsOutbound(t *testing.T) {
+	dist := newDispatcher(t, WithLimits(Limits{MaxMessageSize: 100}))
+	_, err := dist.CallReturn("this", "nothing", strings.Repeat("x", 100))
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("LimitExceededError expected, got %v", err)
+	}
+	if got, want := limitErr.Limit, "MaxMessageSize"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// Go and Javascript calling each other
+	dist = newDispatcher(t, WithLimits(Limits{MaxCallDepth: 3}))
+	dist.RegisterFunc("down", func(m MessageSend) (interface{}, error) {
+		return dist.CallReturn("this", "up")
+	})
+	if err := dist.Worker().Load("recursion.js", `
+	function up() { return V8D.callReturn("", "down"); }`); err != nil {
+		t.Fatal(err)
+	}
+	_, err = dist.CallReturn("this", "up")
+	if err == nil || !strings.Contains(err.Error(), "MaxCallDepth") {
+		t.Errorf("MaxCallDepth error expected, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package v8dispatcher
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ManifestName is the name of the optional file that lists the scripts to load, in order.
+// Each non-empty line holds a script path (relative to the file system root); lines starting with # are ignored.
+const ManifestName = "v8d.manifest"
+
+// LoadError describes a script that failed to load.
+type LoadError struct {
+	// Source is the name of the script as passed to the worker.
+	Source string
+	// Line is the line number reported by Javascript, 0 if unknown.
+	Line int
+	// Err is the original error.
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Source, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *LoadError) Unwrap() error { return e.Err }
+
+// LoadErrors collects all the errors of a LoadFS or LoadDir call.
+type LoadErrors []*LoadError
+
+func (e LoadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, each := range e {
+		msgs[i] = each.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// scriptSource remembers which scripts were loaded from a file system, for reloading.
+type scriptSource struct {
+	fsys     fs.FS
+	prefix   string
+	patterns []string
+}
+
+// LoadFS loads all scripts in fsys that match the patterns (see fs.Glob), in order.
+// Matches of one pattern are loaded in lexical order; a script matched by more than one pattern is loaded once.
+// If no patterns are given then the scripts listed in the ManifestName file are loaded or,
+// if that file does not exist, all "*.js" files.
+// All scripts are attempted; failures are returned as LoadErrors.
+func (d *MessageDispatcher) LoadFS(fsys fs.FS, patterns ...string) error {
+	return d.loadSource(scriptSource{fsys: fsys, patterns: patterns}, true)
+}
+
+// LoadDir loads all scripts in the directory dir that match the patterns. See LoadFS.
+// Scripts are named by their path including dir such that stack traces refer to the actual file.
+func (d *MessageDispatcher) LoadDir(dir string, patterns ...string) error {
+	return d.loadSource(scriptSource{fsys: os.DirFS(dir), prefix: dir, patterns: patterns}, true)
+}
+
+// Reload loads again all scripts that were loaded using LoadFS or LoadDir.
+// Scripts are loaded into the same worker, so definitions are replaced but not removed.
+func (d *MessageDispatcher) Reload() error {
+	var errs LoadErrors
+	for _, each := range d.scriptSources {
+		if err := d.loadSource(each, false); err != nil {
+			var loadErrs LoadErrors
+			if !errors.As(err, &loadErrs) {
+				return err
+			}
+			errs = append(errs, loadErrs...)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *MessageDispatcher) loadSource(src scriptSource, remember bool) error {
+	if d.closed {
+		return ErrClosed
+	}
+	names, err := scriptNames(src.fsys, src.patterns)
+	if err != nil {
+		return err
+	}
+	if remember {
+		d.scriptSources = append(d.scriptSources, src)
+	}
+	var errs LoadErrors
+	for _, each := range names {
+		source := each
+		if len(src.prefix) > 0 {
+			source = filepath.Join(src.prefix, filepath.FromSlash(each))
+		}
+		data, err := fs.ReadFile(src.fsys, each)
+		if err != nil {
+			errs = append(errs, &LoadError{Source: source, Err: err})
+			continue
+		}
+		if d.traceEnabled {
+			d.logger.Info("load", "source", source)
+		}
+		if err := d.worker.Load(source, string(data)); err != nil {
+			errs = append(errs, &LoadError{Source: source, Line: errorLine(source, err), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// scriptNames returns the ordered list of script paths in fsys.
+func scriptNames(fsys fs.FS, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		manifest, err := fs.ReadFile(fsys, ManifestName)
+		if err == nil {
+			return manifestNames(manifest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		patterns = []string{"*.js"}
+	}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, each := range patterns {
+		matches, err := fs.Glob(fsys, each)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				names = append(names, match)
+			}
+		}
+	}
+	return names, nil
+}
+
+// manifestNames returns the script paths listed in a manifest.
+func manifestNames(manifest []byte) []string {
+	names := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, path.Clean(line))
+	}
+	return names
+}
+
+// errorLine extracts the line number from a worker error formatted as "source:line: message".
+func errorLine(source string, err error) int {
+	msg := strings.TrimPrefix(err.Error(), source+":")
+	if len(msg) == len(err.Error()) {
+		return 0
+	}
+	end := strings.IndexByte(msg, ':')
+	if end == -1 {
+		return 0
+	}
+	line, convErr := strconv.Atoi(msg[:end])
+	if convErr != nil {
+		return 0
+	}
+	return line
+}
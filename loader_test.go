@@ -0,0 +1,108 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFSOrder(t *testing.T) {
+	dist := newDispatcher(t)
+	fsys := fstest.MapFS{
+		"b.js":     {Data: []byte(`order.push("b");`)},
+		"a.js":     {Data: []byte(`var order = []; order.push("a");`)},
+		"lib/c.js": {Data: []byte(`order.push("c");`)},
+		"notes.md": {Data: []byte(`not a script`)},
+	}
+	if err := dist.LoadFS(fsys, "*.js", "lib/*.js"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := dist.Get("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(v.([]interface{})), 3; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := v.([]interface{})[2], "c"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestLoadFSManifest(t *testing.T) {
+	dist := newDispatcher(t)
+	fsys := fstest.MapFS{
+		ManifestName: {Data: []byte("# load z first\nz.js\n\na.js\n")},
+		"a.js":       {Data: []byte(`order.push("a");`)},
+		"z.js":       {Data: []byte(`var order = ["z"];`)},
+	}
+	if err := dist.LoadFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+	v, err := dist.Get("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(v.([]interface{})), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestLoadFSErrors(t *testing.T) {
+	dist := newDispatcher(t)
+	fsys := fstest.MapFS{
+		"a.js": {Data: []byte(`throw new Error("a failed");`)},
+		"b.js": {Data: []byte(`var loaded = true;`)},
+		"c.js": {Data: []byte(`var c = ;`)},
+	}
+	err := dist.LoadFS(fsys)
+	var errs LoadErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("LoadErrors expected, got %v", err)
+	}
+	if got, want := len(errs), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := errs[0].Source, "a.js"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := errs[1].Source, "c.js"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if v, _ := dist.Get("loaded"); v != true {
+		t.Errorf("b.js must be loaded, got %v", v)
+	}
+}
+
+func TestLoadDirReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "count.js")
+	if err := os.WriteFile(file, []byte(`var count = 1;`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := newDispatcher(t)
+	if err := dist.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(`var count = 2;`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dist.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := dist.Get("count"); v != float64(2) {
+		t.Errorf("got %v want 2", v)
+	}
+}
+
+func TestErrorLine(t *testing.T) {
+	err := errors.New("some/script.js:12: ReferenceError: x is not defined")
+	if got, want := errorLine("some/script.js", err), 12; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := errorLine("other.js", err), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -1,31 +1,181 @@
 package v8dispatcher
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
 )
 
-// Log can be used to inject your own logging framework
+// Log can be used to inject your own logging framework.
+// It is used by ConsoleLog and, if replaced before a MessageDispatcher is created without WithLogger,
+// for the diagnostics of that dispatcher. The level is one of "debug", "info", "warn" or "error".
+// The default forwards to slog.Default() with the key-value pairs as attributes.
 var Log = func(level, msg string, kvs ...interface{}) {
-	// default uses standard logging
-	buf := new(bytes.Buffer)
-	fmt.Fprintf(buf, "[%s] %s", level, msg)
-	for i := 0; i < len(kvs); i = i + 2 {
-		var v interface{}
-		if len(kvs) == i+1 {
-			v = "*** missing ***"
-		} else {
-			v = kvs[i+1]
-		}
-		fmt.Fprintf(buf, ", %v = %v", kvs[i], v)
+	slog.Default().Log(context.Background(), logLevel(level), msg, kvs...)
+}
+
+// defaultLog is the initial value of Log.
+var defaultLog = Log
+
+// WithLogger sets the logger for the diagnostics of the dispatcher.
+// Default is slog.Default(), or a logger that calls Log if Log was replaced.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// defaultLogger returns the logger of a dispatcher created without WithLogger.
+func defaultLogger() *slog.Logger {
+	if reflect.ValueOf(Log).Pointer() == reflect.ValueOf(defaultLog).Pointer() {
+		return slog.Default()
+	}
+	return slog.New(logHandler{})
+}
+
+// logHandler is a slog.Handler that calls Log with the attributes of a record as key-value pairs.
+// Keys of attributes in groups are prefixed with the group names, e.g. "group.key".
+type logHandler struct {
+	kvs    []interface{}
+	prefix string
+}
+
+func (h logHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h logHandler) Handle(_ context.Context, r slog.Record) error {
+	kvs := append([]interface{}{}, h.kvs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = append(kvs, h.prefix+a.Key, a.Value.Resolve().Any())
+		return true
+	})
+	Log(levelName(r.Level), r.Message, kvs...)
+	return nil
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kvs := append([]interface{}{}, h.kvs...)
+	for _, each := range attrs {
+		kvs = append(kvs, h.prefix+each.Key, each.Value.Resolve().Any())
 	}
-	log.Println(buf.String())
+	return logHandler{kvs: kvs, prefix: h.prefix}
 }
 
-// ConsoleLog is the default registered function for "console.log"
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{kvs: h.kvs, prefix: h.prefix + name + "."}
+}
+
+// levelName returns the level name used with Log for a slog level.
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// logLevel returns the slog level for a level name used with Log.
+func logLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// consoleLevels maps the selectors of the Javascript console (see js/console.js) to log levels.
+var consoleLevels = map[string]slog.Level{
+	"log":      slog.LevelInfo,
+	"debug":    slog.LevelDebug,
+	"info":     slog.LevelInfo,
+	"warn":     slog.LevelWarn,
+	"error":    slog.LevelError,
+	"trace":    slog.LevelDebug,
+	"assert":   slog.LevelError,
+	"timeEnd":  slog.LevelInfo,
+	"count":    slog.LevelInfo,
+	"group":    slog.LevelInfo,
+	"groupEnd": slog.LevelInfo,
+	"table":    slog.LevelInfo,
+}
+
+// ConsoleLevel returns the log level for a console selector, e.g. "warn". Unknown selectors are logged at info.
+func ConsoleLevel(selector string) slog.Level {
+	if level, ok := consoleLevels[selector]; ok {
+		return level
+	}
+	return slog.LevelInfo
+}
+
+// ConsoleLog is the default registered function for "console.log" and the other console selectors.
+// The arguments make up the message; the script name and line are passed to Log as "script" and "line".
 // Register your own function to override this behavior.
 func ConsoleLog(msg MessageSend) (interface{}, error) {
-	Log("info", msg.Receiver+"."+msg.Selector, msg.Arguments...)
+	kvs := []interface{}{}
+	if len(msg.Source) > 0 {
+		kvs = append(kvs, "script", msg.Source, "line", msg.Line)
+	}
+	Log(levelName(ConsoleLevel(msg.Selector)), consoleMessage(msg.Arguments), kvs...)
 	return nil, nil
 }
+
+// SlogConsole returns a function for handling console MessageSends by logging records with the logger.
+// The arguments make up the message; the script name and line are added as "script" and "line" attributes.
+// Selectors "trace", "timeEnd", "count" and "table" add the "stack", "duration", "count" and "data" attributes.
+func SlogConsole(logger *slog.Logger) MessageSendHandlerFunc {
+	return func(msg MessageSend) (interface{}, error) {
+		args := msg.Arguments
+		attrs := []slog.Attr{}
+		if len(msg.Source) > 0 {
+			attrs = append(attrs, slog.String("script", msg.Source), slog.Int("line", msg.Line))
+		}
+		switch msg.Selector {
+		case "trace":
+			if len(args) > 0 {
+				attrs = append(attrs, slog.Any("stack", args[len(args)-1]))
+				args = args[:len(args)-1]
+			}
+		case "timeEnd":
+			if len(args) == 2 {
+				if ms, ok := args[1].(float64); ok {
+					attrs = append(attrs, slog.Duration("duration", time.Duration(ms)*time.Millisecond))
+					args = args[:1]
+				}
+			}
+		case "count":
+			if len(args) == 2 {
+				attrs = append(attrs, slog.Any("count", args[1]))
+				args = args[:1]
+			}
+		case "table":
+			if len(args) > 0 {
+				attrs = append(attrs, slog.Any("data", args[0]))
+				if len(args) > 1 {
+					attrs = append(attrs, slog.Any("columns", args[1]))
+				}
+				args = []interface{}{"table"}
+			}
+		}
+		logger.LogAttrs(context.Background(), ConsoleLevel(msg.Selector), consoleMessage(args), attrs...)
+		return nil, nil
+	}
+}
+
+// consoleMessage joins the arguments separated by spaces.
+func consoleMessage(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, each := range args {
+		parts[i] = fmt.Sprint(each)
+	}
+	return strings.Join(parts, " ")
+}
+
+// WithConsoleLogger makes the dispatcher handle all console MessageSends using SlogConsole with the logger.
+func WithConsoleLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.consoleHandler = SlogConsole(logger)
+	}
+}
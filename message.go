@@ -21,6 +21,18 @@ type MessageSend struct {
 
 	// IsAsynchronous is to used to indicate that no return value is expected
 	IsAsynchronous bool `json:"async"`
+
+	// Source is the name of the script that sent the message, if known.
+	Source string `json:"source,omitempty"`
+
+	// Line is the line number in Source from which the message was sent, if known.
+	Line int `json:"line,omitempty"`
+
+	// TraceID identifies the trace this message is part of, if tracing is enabled.
+	TraceID string `json:"trace,omitempty"`
+
+	// SpanID identifies the span of the sender, which is the parent of the span of the receiver.
+	SpanID string `json:"span,omitempty"`
 }
 
 func (m MessageSend) JSON() (string, error) {
@@ -0,0 +1,223 @@
+package v8dispatcher
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Observation is the measurement of one MessageSend.
+type Observation struct {
+	Receiver string
+	Selector string
+	// Direction is "inbound" for Javascript to Go and "outbound" for Go to Javascript.
+	Direction string
+	Duration  time.Duration
+	// RequestSize is the number of bytes of the JSON MessageSend.
+	RequestSize int
+	// ReplySize is the number of bytes of the JSON reply, 0 if none.
+	ReplySize int
+	// Err is non-nil if the MessageSend failed.
+	Err error
+}
+
+// Metrics receives an Observation for each MessageSend handled or sent by a dispatcher.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	Observe(Observation)
+}
+
+// WithMetrics makes the dispatcher report an Observation for each MessageSend to m.
+// Using WithMetrics more than once reports to all of them, in order.
+func WithMetrics(m Metrics) Option {
+	return func(c *config) {
+		if c.metrics != nil {
+			c.metrics = metricsChain{c.metrics, m}
+			return
+		}
+		c.metrics = m
+	}
+}
+
+// metricsChain reports each Observation to all its Metrics.
+type metricsChain []Metrics
+
+func (c metricsChain) Observe(o Observation) {
+	for _, each := range c {
+		each.Observe(o)
+	}
+}
+
+// observe reports a MessageSend that started at start to the metrics and the recording, if any.
+func (d *MessageDispatcher) observe(msg MessageSend, direction string, start time.Time, requestSize int, reply string, err error) {
+	duration := time.Since(start)
+	if d.recorder != nil {
+		d.recorder.record(d.logger, msg, direction, start, duration, reply, err)
+	}
+	if d.metrics == nil {
+		return
+	}
+	d.metrics.Observe(Observation{
+		Receiver:    msg.Receiver,
+		Selector:    msg.Selector,
+		Direction:   direction,
+		Duration:    duration,
+		RequestSize: requestSize,
+		ReplySize:   len(reply),
+		Err:         err,
+	})
+}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histogram buckets of a MemoryMetrics.
+var DefaultLatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// MessageStats holds the aggregated observations of one receiver, selector and direction.
+type MessageStats struct {
+	Receiver     string
+	Selector     string
+	Direction    string
+	Count        uint64
+	Errors       uint64
+	RequestBytes uint64
+	ReplyBytes   uint64
+	// Buckets are the upper bounds (in seconds) of the latency histogram.
+	Buckets []float64
+	// BucketCounts are the cumulative counts for each bucket bound.
+	BucketCounts []uint64
+	// TotalDuration is the sum of all latencies.
+	TotalDuration time.Duration
+}
+
+type statsKey struct {
+	receiver, selector, direction string
+}
+
+// MemoryMetrics is a Metrics that aggregates observations in memory.
+// It is an http.Handler that writes all stats in the Prometheus text exposition format.
+type MemoryMetrics struct {
+	mutex   sync.Mutex
+	buckets []float64
+	stats   map[statsKey]*MessageStats
+}
+
+// NewMemoryMetrics returns a MemoryMetrics using the latency buckets (seconds, ascending).
+// If no buckets are given then DefaultLatencyBuckets are used.
+func NewMemoryMetrics(buckets ...float64) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	return &MemoryMetrics{
+		buckets: buckets,
+		stats:   map[statsKey]*MessageStats{},
+	}
+}
+
+// Observe is part of Metrics.
+func (m *MemoryMetrics) Observe(o Observation) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := statsKey{o.Receiver, o.Selector, o.Direction}
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &MessageStats{
+			Receiver:     o.Receiver,
+			Selector:     o.Selector,
+			Direction:    o.Direction,
+			Buckets:      m.buckets,
+			BucketCounts: make([]uint64, len(m.buckets)),
+		}
+		m.stats[key] = stats
+	}
+	stats.Count++
+	if o.Err != nil {
+		stats.Errors++
+	}
+	stats.RequestBytes += uint64(o.RequestSize)
+	stats.ReplyBytes += uint64(o.ReplySize)
+	stats.TotalDuration += o.Duration
+	seconds := o.Duration.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			stats.BucketCounts[i]++
+		}
+	}
+}
+
+// Snapshot returns a copy of all stats, ordered by direction, receiver and selector.
+func (m *MemoryMetrics) Snapshot() []MessageStats {
+	m.mutex.Lock()
+	list := make([]MessageStats, 0, len(m.stats))
+	for _, each := range m.stats {
+		copied := *each
+		copied.BucketCounts = append([]uint64{}, each.BucketCounts...)
+		list = append(list, copied)
+	}
+	m.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Direction != list[j].Direction {
+			return list[i].Direction < list[j].Direction
+		}
+		if list[i].Receiver != list[j].Receiver {
+			return list[i].Receiver < list[j].Receiver
+		}
+		return list[i].Selector < list[j].Selector
+	})
+	return list
+}
+
+// Reset removes all stats.
+func (m *MemoryMetrics) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stats = map[statsKey]*MessageStats{}
+}
+
+// ServeHTTP writes all stats in the Prometheus text exposition format.
+func (m *MemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// WritePrometheus writes all stats in the Prometheus text exposition format.
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) {
+	list := m.Snapshot()
+	counter := func(name, help string, value func(MessageStats) uint64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, each := range list {
+			fmt.Fprintf(w, "%s{%s} %d\n", name, labels(each), value(each))
+		}
+	}
+	counter("v8dispatcher_messages_total", "Number of MessageSends.",
+		func(s MessageStats) uint64 { return s.Count })
+	counter("v8dispatcher_message_errors_total", "Number of failed MessageSends.",
+		func(s MessageStats) uint64 { return s.Errors })
+	counter("v8dispatcher_message_request_bytes_total", "Number of bytes of JSON MessageSends.",
+		func(s MessageStats) uint64 { return s.RequestBytes })
+	counter("v8dispatcher_message_reply_bytes_total", "Number of bytes of JSON replies.",
+		func(s MessageStats) uint64 { return s.ReplyBytes })
+
+	name := "v8dispatcher_message_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of MessageSends.\n# TYPE %s histogram\n", name, name)
+	for _, each := range list {
+		l := labels(each)
+		for i, bound := range each.Buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, l, bound, each.BucketCounts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, each.Count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, l, each.TotalDuration.Seconds())
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, l, each.Count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(s MessageStats) string {
+	return fmt.Sprintf(`direction="%s",receiver="%s",selector="%s"`,
+		labelEscaper.Replace(s.Direction),
+		labelEscaper.Replace(s.Receiver),
+		labelEscaper.Replace(s.Selector))
+}
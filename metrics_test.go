@@ -0,0 +1,70 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	metrics := NewMemoryMetrics()
+	dist := newDispatcher(t, WithMetrics(metrics))
+	dist.RegisterFunc("fail", func(msg MessageSend) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	if err := dist.Worker().Load("TestMemoryMetrics.js", `
+		function twice(x) { return x * 2; }
+		V8D.call("","fail");
+		V8D.call("","fail");
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dist.CallReturn("this", "twice", 21); err != nil {
+		t.Fatal(err)
+	}
+	var fail, twice *MessageStats
+	for _, each := range metrics.Snapshot() {
+		each := each
+		switch each.Selector {
+		case "fail":
+			fail = &each
+		case "twice":
+			twice = &each
+		}
+	}
+	if fail == nil || twice == nil {
+		t.Fatalf("missing stats: %v", metrics.Snapshot())
+	}
+	if got, want := fail.Count, uint64(2); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := fail.Errors, uint64(2); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := fail.Direction, "inbound"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := twice.Direction, "outbound"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := twice.ReplyBytes, uint64(len("42")); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if twice.RequestBytes == 0 {
+		t.Error("request bytes expected")
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, each := range []string{
+		`v8dispatcher_message_errors_total{direction="inbound",receiver="",selector="fail"} 2`,
+		`v8dispatcher_message_duration_seconds_count{direction="outbound",receiver="this",selector="twice"} 1`,
+		`# TYPE v8dispatcher_message_duration_seconds histogram`,
+	} {
+		if !strings.Contains(body, each) {
+			t.Errorf("missing %q in\n%s", each, body)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package v8dispatcher
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Script is a named Javascript source.
+type Script struct {
+	Name   string
+	Source string
+}
+
+// Option configures a MessageDispatcher when it is created.
+type Option func(*config)
+
+// config holds the settings a MessageDispatcher is created with.
+type config struct {
+	runtime    []Script
+	runtimeSet bool
+	// verified is set if the runtime version is known to be compatible
+	verified  bool
+	bootstrap []Script
+	console   bool
+	// consoleHandler is registered for all console selectors
+	consoleHandler MessageSendHandlerFunc
+	logger         *slog.Logger
+	metrics        Metrics
+	spanExporter   SpanExporter
+	recording      io.Writer
+	replayer       *Replayer
+	timers         bool
+	limits         Limits
+	policy         *Policy
+	quotas         map[string]Quota
+	quotaAlert     func(*QuotaExceededError)
+	determinism    *determinism
+}
+
+func newConfig(options []Option) *config {
+	c := &config{console: true, consoleHandler: ConsoleLog}
+	for _, each := range options {
+		each(c)
+	}
+	if c.logger == nil {
+		c.logger = defaultLogger()
+	}
+	if !c.runtimeSet {
+		for _, each := range runtimeScripts {
+			c.runtime = append(c.runtime, Script{Name: each, Source: runtimeSource(each)})
+		}
+	}
+	return c
+}
+
+// WithoutConsole prevents loading the "console.js" runtime script, which defines the console global in Javascript,
+// and the registration of the default console handlers.
+func WithoutConsole() Option {
+	return func(c *config) {
+		c.console = false
+	}
+}
+
+// WithRuntime replaces the embedded Javascript runtime (see js folder) by the given scripts.
+// The runtime must define a compatible V8D.version, see RuntimeVersion.
+func WithRuntime(scripts ...Script) Option {
+	return func(c *config) {
+		c.runtime = scripts
+		c.runtimeSet = true
+	}
+}
+
+// WithBootstrapScripts adds scripts that are loaded after the runtime.
+func WithBootstrapScripts(scripts ...Script) Option {
+	return func(c *config) {
+		c.bootstrap = append(c.bootstrap, scripts...)
+	}
+}
@@ -0,0 +1,63 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithoutConsole(t *testing.T) {
+	dist := newDispatcher(t, WithoutConsole())
+	if _, ok := dist.messageHandlerFuncs["console.log"]; ok {
+		t.Error("console.log handler not expected")
+	}
+	v, err := dist.CallReturn("this", "eval", `typeof console`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "undefined"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWithBootstrapScripts(t *testing.T) {
+	dist := newDispatcher(t, WithBootstrapScripts(Script{Name: "pi.js", Source: `var pi = 3.14;`}))
+	v, err := dist.Get("pi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, 3.14; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWithBootstrapScriptsError(t *testing.T) {
+	_, err := NewMessageDispatcher(WithBootstrapScripts(Script{Name: "bad.js", Source: `var x = ;`}))
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("LoadError expected, got %v", err)
+	}
+	if got, want := loadErr.Source, "bad.js"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWithRuntimeIncompatible(t *testing.T) {
+	scripts := []Script{}
+	for _, each := range runtimeScripts {
+		scripts = append(scripts, Script{Name: each, Source: runtimeSource(each)})
+	}
+	for _, each := range []string{"2.0.0", "1.0.0", "1.5.9", "1"} {
+		version := Script{Name: "version.js", Source: fmt.Sprintf("V8D.version = %q;", each)}
+		if _, err := NewMessageDispatcher(WithRuntime(append(scripts, version)...)); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+	// a newer minor version is compatible
+	newer := Script{Name: "version.js", Source: `V8D.version = "1.99.0";`}
+	d, err := NewMessageDispatcher(WithRuntime(append(scripts, newer)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+}
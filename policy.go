@@ -0,0 +1,130 @@
+package v8dispatcher
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Policy lists the receivers and selectors of Go handlers that Javascript is allowed to call; all others are denied.
+// A receiver is the name of a registered MessageSendHandler, or the empty string for functions registered by selector.
+// The console selectors (log, warn, etc.) and the V8D.ids, V8D.timers and V8D.clock receivers of the runtime
+// are always allowed; other selectors of the console receiver are not.
+//
+// A Policy is attached to a MessageDispatcher, see WithPolicy.
+// Scripts of different principals (e.g. tenants) should use separate dispatchers, each with its own Policy,
+// because all scripts in one dispatcher share the same global environment.
+type Policy struct {
+	// Name identifies the principal in logs and errors.
+	Name string
+	// allowed maps receivers to selectors; a nil set allows all selectors
+	allowed map[string]map[string]bool
+}
+
+// NewPolicy returns a Policy that denies all receivers.
+func NewPolicy(name string) *Policy {
+	return &Policy{Name: name, allowed: map[string]map[string]bool{}}
+}
+
+// Allow adds permission to call the selectors of a receiver; without selectors all are allowed.
+// Use the empty receiver for functions registered by selector only.
+func (p *Policy) Allow(receiver string, selectors ...string) *Policy {
+	if len(selectors) == 0 {
+		p.allowed[receiver] = nil
+		return p
+	}
+	set, ok := p.allowed[receiver]
+	if ok && set == nil {
+		// already all selectors
+		return p
+	}
+	if set == nil {
+		set = map[string]bool{}
+		p.allowed[receiver] = set
+	}
+	for _, each := range selectors {
+		set[each] = true
+	}
+	return p
+}
+
+// Allows returns whether Javascript may perform the MessageSend.
+func (p *Policy) Allows(msg MessageSend) bool {
+	if runtimeMessage(msg) {
+		return true
+	}
+	set, ok := p.allowed[msg.Receiver]
+	if !ok {
+		return false
+	}
+	return set == nil || set[msg.Selector]
+}
+
+// String returns the allowed receiver.selector combinations, sorted.
+func (p *Policy) String() string {
+	allowed := []string{}
+	for receiver, set := range p.allowed {
+		if set == nil {
+			allowed = append(allowed, receiver+".*")
+			continue
+		}
+		for selector := range set {
+			allowed = append(allowed, receiver+"."+selector)
+		}
+	}
+	sort.Strings(allowed)
+	return fmt.Sprintf("%s[%s]", p.Name, strings.Join(allowed, " "))
+}
+
+// runtimeReceiver returns whether the receiver is a handler of the dispatcher itself for the Javascript runtime.
+func runtimeReceiver(receiver string) bool {
+	switch receiver {
+	case "V8D.ids", "V8D.timers", "V8D.clock":
+		return true
+	}
+	return false
+}
+
+// runtimeMessage returns whether the message is sent by the Javascript runtime: to a runtime receiver
+// or one of the console selectors. Other console selectors are treated as any other message.
+func runtimeMessage(msg MessageSend) bool {
+	if msg.Receiver == "console" {
+		_, ok := consoleLevels[msg.Selector]
+		return ok
+	}
+	return runtimeReceiver(msg.Receiver)
+}
+
+// WithPolicy restricts the Go handlers that Javascript can call.
+// A denied synchronous call throws a PolicyError in Javascript; all denied calls are logged.
+func WithPolicy(p *Policy) Option {
+	return func(c *config) {
+		c.policy = p
+	}
+}
+
+// PolicyError is thrown in Javascript if a Policy denies a MessageSend.
+type PolicyError struct {
+	// Policy is the name of the denying Policy.
+	Policy string
+	// Receiver and Selector identify the MessageSend.
+	Receiver, Selector string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy %q denies %s.%s", e.Policy, e.Receiver, e.Selector)
+}
+
+// errorName is the name of the Error thrown in Javascript.
+func (e *PolicyError) errorName() string { return "PolicyError" }
+
+// authorize returns a PolicyError, and logs it, if the policy of the dispatcher denies the message.
+func (d *MessageDispatcher) authorize(msg MessageSend) error {
+	if d.policy == nil || d.policy.Allows(msg) {
+		return nil
+	}
+	err := &PolicyError{Policy: d.policy.Name, Receiver: msg.Receiver, Selector: msg.Selector}
+	d.log(slog.LevelWarn, "denied", msg, directionInbound, slog.String("policy", d.policy.Name))
+	return err
+}
@@ -0,0 +1,57 @@
+package v8dispatcher
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := NewPolicy("tenant").Allow("storage", "get").Allow("", "now")
+	logs := new(bytes.Buffer)
+	dist := newDispatcher(t, WithPolicy(policy), WithLogger(slog.New(slog.NewTextHandler(logs, nil))))
+	performed := []string{}
+	handler := func(m MessageSend) (interface{}, error) {
+		performed = append(performed, m.Receiver+"."+m.Selector)
+		return "ok", nil
+	}
+	dist.RegisterFunc("now", handler)
+	dist.RegisterFunc("shutdown", handler)
+	dist.RegisterFunc("storage.get", handler)
+	dist.RegisterFunc("storage.delete", handler)
+	dist.RegisterFunc("console.exec", handler)
+	if err := dist.Worker().Load("policy.js", `
+	function attempt(receiver, selector) {
+		try { return V8D.callReturn(receiver, selector); } catch (e) { return e.name + ": " + e.message; }
+	}
+	console.log("allowed");`); err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		receiver, selector, want string
+	}{
+		{"", "now", "ok"},
+		{"storage", "get", "ok"},
+		{"", "shutdown", `PolicyError: policy "tenant" denies .shutdown`},
+		{"storage", "delete", `PolicyError: policy "tenant" denies storage.delete`},
+		{"console", "exec", `PolicyError: policy "tenant" denies console.exec`},
+	} {
+		v, err := dist.CallReturn("this", "attempt", each.receiver, each.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, each.want; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	if got, want := strings.Join(performed, " "), ".now storage.get"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := strings.Count(logs.String(), "msg=denied"), 3; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := policy.String(), "tenant[.now storage.get]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
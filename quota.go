@@ -0,0 +1,153 @@
+package v8dispatcher
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Quota limits the calls from Javascript to Go handlers; a zero field means no limit.
+type Quota struct {
+	// PerSecond is the rate at which calls are allowed, on average.
+	PerSecond float64
+	// Burst is the number of calls allowed at once; at least 1 if PerSecond is set.
+	Burst int
+	// Total is the number of calls allowed during the lifetime of the dispatcher.
+	Total int
+}
+
+// WithQuota limits the calls from Javascript to a selector of a receiver.
+// Use "*" as the selector to limit all calls to the receiver and "*" for both to limit all calls to the dispatcher.
+// The empty receiver is used for functions registered by selector only.
+// Calls to the console selectors and to the V8D.ids, V8D.timers and V8D.clock receivers of the runtime are not limited.
+// A call must be within all matching quotas; a call that exceeds one is not performed,
+// is logged and, if synchronous, throws a QuotaExceededError in Javascript.
+func WithQuota(receiver, selector string, q Quota) Option {
+	return func(c *config) {
+		if c.quotas == nil {
+			c.quotas = map[string]Quota{}
+		}
+		c.quotas[quotaKey(receiver, selector)] = q
+	}
+}
+
+// WithQuotaAlert sets a function that is called with each call that exceeds a quota, e.g. for alerting.
+// The function is called on the goroutine that uses the dispatcher; it must not use the dispatcher.
+func WithQuotaAlert(alert func(*QuotaExceededError)) Option {
+	return func(c *config) {
+		c.quotaAlert = alert
+	}
+}
+
+// QuotaExceededError is thrown in Javascript if a call exceeds a Quota.
+type QuotaExceededError struct {
+	// Receiver and Selector identify the MessageSend.
+	Receiver, Selector string
+	// Quota is the receiver.selector of the exceeded Quota, e.g. "storage.*" or "*.*".
+	Quota string
+	// Limit is the exceeded part of the Quota: "rate" or "total".
+	Limit string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s of %s (%s.%s)", e.Limit, e.Quota, e.Receiver, e.Selector)
+}
+
+// errorName is the name of the Error thrown in Javascript.
+func (e *QuotaExceededError) errorName() string { return "QuotaExceededError" }
+
+func quotaKey(receiver, selector string) string {
+	return receiver + "." + selector
+}
+
+// bucket keeps the usage of one Quota.
+type bucket struct {
+	quota  Quota
+	tokens float64
+	last   time.Time
+	used   int
+}
+
+// exceeded refills the tokens and returns the exceeded limit, or "" if a call is allowed.
+func (b *bucket) exceeded(now time.Time) string {
+	if b.quota.Total > 0 && b.used >= b.quota.Total {
+		return "total"
+	}
+	if b.quota.PerSecond <= 0 {
+		return ""
+	}
+	burst := float64(max(b.quota.Burst, 1))
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*b.quota.PerSecond)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return "rate"
+	}
+	return ""
+}
+
+// take uses a call of the quota.
+func (b *bucket) take() {
+	b.used++
+	if b.quota.PerSecond > 0 {
+		b.tokens--
+	}
+}
+
+// quotaLimiter keeps the buckets of all quotas of a dispatcher.
+type quotaLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	alert   func(*QuotaExceededError)
+	now     func() time.Time
+}
+
+func newQuotaLimiter(quotas map[string]Quota, alert func(*QuotaExceededError)) *quotaLimiter {
+	l := &quotaLimiter{buckets: map[string]*bucket{}, alert: alert, now: time.Now}
+	for key, each := range quotas {
+		l.buckets[key] = &bucket{quota: each}
+	}
+	return l
+}
+
+// allow returns a QuotaExceededError if the message exceeds any matching quota; otherwise it uses a call of all of them.
+func (l *quotaLimiter) allow(msg MessageSend) *QuotaExceededError {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	matching := []*bucket{}
+	for _, key := range []string{quotaKey(msg.Receiver, msg.Selector), quotaKey(msg.Receiver, "*"), quotaKey("*", "*")} {
+		b, ok := l.buckets[key]
+		if !ok {
+			continue
+		}
+		if limit := b.exceeded(now); len(limit) > 0 {
+			return &QuotaExceededError{Receiver: msg.Receiver, Selector: msg.Selector, Quota: key, Limit: limit}
+		}
+		matching = append(matching, b)
+	}
+	for _, each := range matching {
+		each.take()
+	}
+	return nil
+}
+
+// checkQuota returns a QuotaExceededError, logs it and calls the alert, if the message exceeds a quota.
+func (d *MessageDispatcher) checkQuota(msg MessageSend) error {
+	if d.quotas == nil || runtimeMessage(msg) {
+		return nil
+	}
+	err := d.quotas.allow(msg)
+	if err == nil {
+		return nil
+	}
+	d.log(slog.LevelWarn, "quota exceeded", msg, directionInbound, slog.String("quota", err.Quota), slog.String("limit", err.Limit))
+	if d.quotas.alert != nil {
+		d.quotas.alert(err)
+	}
+	return err
+}
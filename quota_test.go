@@ -0,0 +1,56 @@
+package v8dispatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuota(t *testing.T) {
+	alerts := []*QuotaExceededError{}
+	dist := newDispatcher(t,
+		WithQuota("", "handleEvent", Quota{PerSecond: 1, Burst: 2}),
+		WithQuota("*", "*", Quota{Total: 4}),
+		WithQuotaAlert(func(e *QuotaExceededError) { alerts = append(alerts, e) }))
+	now := time.Date(2016, 2, 8, 0, 0, 0, 0, time.UTC)
+	dist.quotas.now = func() time.Time { return now }
+	performed := 0
+	dist.RegisterFunc("handleEvent", func(m MessageSend) (interface{}, error) {
+		performed++
+		return nil, nil
+	})
+	if err := dist.Worker().Load("quota.js", `
+	function attempt() {
+		try { V8D.callReturn("", "handleEvent"); return ""; } catch (e) { return e.name + ": " + e.message; }
+	}`); err != nil {
+		t.Fatal(err)
+	}
+	for i, each := range []struct {
+		after time.Duration
+		want  string
+	}{
+		{0, ""},
+		{0, ""},
+		{0, "QuotaExceededError: quota exceeded: rate of .handleEvent (.handleEvent)"},
+		{time.Second, ""},
+		{10 * time.Second, ""},
+		{10 * time.Second, "QuotaExceededError: quota exceeded: total of *.* (.handleEvent)"},
+	} {
+		now = now.Add(each.after)
+		v, err := dist.CallReturn("this", "attempt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, each.want; got != want {
+			t.Errorf("%d: got %v want %v", i, got, want)
+		}
+	}
+	if got, want := performed, 4; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(alerts), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := alerts[1].Limit, "total"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package v8dispatcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RecordedMessage is one line of a recording, see WithRecording.
+type RecordedMessage struct {
+	// Sequence is the position of the message in the recording, starting at 1.
+	Sequence int `json:"seq"`
+	// Direction is "inbound" for Javascript to Go and "outbound" for Go to Javascript.
+	Direction string      `json:"direction"`
+	Message   MessageSend `json:"message"`
+	// Reply is the JSON reply, if any.
+	Reply json.RawMessage `json:"reply,omitempty"`
+	// Error is the error message if the MessageSend failed.
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// WithRecording makes the dispatcher write every inbound and outbound MessageSend, with its reply and timing,
+// as a RecordedMessage in JSON Lines format to w.
+func WithRecording(w io.Writer) Option {
+	return func(c *config) {
+		c.recording = w
+	}
+}
+
+// messageRecorder writes RecordedMessages.
+type messageRecorder struct {
+	mutex    sync.Mutex
+	writer   io.Writer
+	sequence int
+}
+
+func (r *messageRecorder) record(logger *slog.Logger, msg MessageSend, direction string, start time.Time, duration time.Duration, reply string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sequence++
+	entry := RecordedMessage{
+		Sequence:  r.sequence,
+		Direction: direction,
+		Message:   msg,
+		Start:     start,
+		Duration:  duration,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if len(reply) > 0 && json.Valid([]byte(reply)) {
+		entry.Reply = json.RawMessage(reply)
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		_, err = r.writer.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logger.Error("recording failed", "receiver", msg.Receiver, "selector", msg.Selector, "direction", direction, "err", err)
+	}
+}
+
+// ErrNotRecorded is returned while replaying if no recorded reply is available for a MessageSend.
+var ErrNotRecorded = errors.New("not recorded")
+
+// Replayer serves the results of Go handlers from a recording, see WithReplay.
+type Replayer struct {
+	mutex   sync.Mutex
+	pending map[string][]RecordedMessage
+}
+
+// NewReplayer reads a recording written using WithRecording.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	p := &Replayer{pending: map[string][]RecordedMessage{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var each RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &each); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		// the console and the runtime are performed while replaying
+		if each.Direction != directionInbound || runtimeMessage(each.Message) {
+			continue
+		}
+		key := replayKey(each.Message)
+		p.pending[key] = append(p.pending[key], each)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WithReplay makes the dispatcher answer MessageSends from Javascript with the recorded results instead of calling the Go handlers.
+// Messages are matched by receiver and selector, in recorded order. Messages to the console selectors and to the
+// V8D.ids, V8D.timers and V8D.clock receivers of the runtime are still performed.
+func WithReplay(p *Replayer) Option {
+	return func(c *config) {
+		c.replayer = p
+	}
+}
+
+// Remaining returns the number of recorded inbound messages that have not been replayed.
+func (p *Replayer) Remaining() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	n := 0
+	for _, each := range p.pending {
+		n += len(each)
+	}
+	return n
+}
+
+// next returns the recorded result of the message.
+func (p *Replayer) next(msg MessageSend) (interface{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	key := replayKey(msg)
+	list := p.pending[key]
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotRecorded, key)
+	}
+	p.pending[key] = list[1:]
+	if len(list[0].Error) > 0 {
+		if list[0].Error == ErrNoHandler.Error() {
+			return nil, ErrNoHandler
+		}
+		return nil, errors.New(list[0].Error)
+	}
+	return list[0].Reply, nil
+}
+
+func replayKey(msg MessageSend) string {
+	return msg.Receiver + "." + msg.Selector
+}
@@ -0,0 +1,115 @@
+package v8dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var diceSrc = `
+	function roll() {
+		return V8D.callReturn("dice","roll") + V8D.callReturn("dice","roll");
+	}
+`
+
+func TestRecordAndReplay(t *testing.T) {
+	recording := new(bytes.Buffer)
+	dist := newDispatcher(t, WithRecording(recording))
+	rolls := []int{3, 5}
+	dist.RegisterFunc("dice.roll", func(msg MessageSend) (interface{}, error) {
+		next := rolls[0]
+		rolls = rolls[1:]
+		return next, nil
+	})
+	if err := dist.Worker().Load("dice.js", diceSrc); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := dist.CallReturn("this", "roll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	var last RecordedMessage
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := last.Direction, "outbound"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := string(last.Reply), "8"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	replayer, err := NewReplayer(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := newDispatcher(t, WithReplay(replayer))
+	if err := replay.Worker().Load("dice.js", diceSrc); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := replay.CallReturn("this", "roll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := replayed, recorded; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := replayer.Remaining(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, err := replayer.next(MessageSend{Receiver: "dice", Selector: "roll"}); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("got %v want %v", err, ErrNotRecorded)
+	}
+}
+
+func TestReplayRuntime(t *testing.T) {
+	src := `
+	var result = 0;
+	function play() {
+		V8D.callThen("dice", "roll", function(value) { result += value; });
+		setTimeout(function() { result += V8D.callReturn("dice", "roll"); }, 1);
+	}`
+	run := func(options ...Option) interface{} {
+		dist := newDispatcher(t, append(options, WithTimers())...)
+		rolls := []int{3, 5}
+		dist.RegisterFunc("dice.roll", func(msg MessageSend) (interface{}, error) {
+			next := rolls[0]
+			rolls = rolls[1:]
+			return next, nil
+		})
+		if err := dist.Worker().Load("play.js", src); err != nil {
+			t.Fatal(err)
+		}
+		if err := dist.Call("this", "play"); err != nil {
+			t.Fatal(err)
+		}
+		if err := dist.RunEventLoop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		v, err := dist.Get("result")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	recording := new(bytes.Buffer)
+	recorded := run(WithRecording(recording))
+	replayer, err := NewReplayer(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := replayer.Remaining(), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	replayed := run(WithReplay(replayer))
+	if got, want := replayed, recorded; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := replayer.Remaining(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
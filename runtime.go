@@ -0,0 +1,73 @@
+package v8dispatcher
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SupportedRuntimeVersion is the version of the Javascript runtime (see js folder) this package is written for.
+// A runtime is compatible if its V8D.version has the same major version and at least the same minor version,
+// because each minor version adds functions that this package calls.
+const SupportedRuntimeVersion = "1.6.0"
+
+//go:embed js/*.js
+var runtimeFS embed.FS
+
+// runtimeScripts lists the scripts of the runtime in load order.
+var runtimeScripts = []string{"registry.js", "setup.js", "console.js"}
+
+// runtimeSource returns the embedded source of a runtime script.
+func runtimeSource(name string) string {
+	data, err := runtimeFS.ReadFile("js/" + name)
+	if err != nil {
+		// cannot happen; all runtimeScripts are embedded
+		panic(err)
+	}
+	return string(data)
+}
+
+// RuntimeVersion returns the value of V8D.version of the loaded Javascript runtime.
+// It returns an error if the version is missing or if it is not compatible with SupportedRuntimeVersion.
+func (d *MessageDispatcher) RuntimeVersion() (string, error) {
+	v, err := d.CallReturn("V8D", "runtimeVersion")
+	if err != nil {
+		return "", err
+	}
+	version, ok := v.(string)
+	if !ok || len(version) == 0 {
+		return "", fmt.Errorf("runtime has no version, got %v", v)
+	}
+	if !compatibleVersion(version, SupportedRuntimeVersion) {
+		return version, fmt.Errorf("runtime version %s is not compatible with %s", version, SupportedRuntimeVersion)
+	}
+	return version, nil
+}
+
+// compatibleVersion returns whether the version has the major version of the supported one and at least its minor version.
+func compatibleVersion(version, supported string) bool {
+	major, minor, ok := majorMinor(version)
+	if !ok {
+		return false
+	}
+	wantMajor, wantMinor, _ := majorMinor(supported)
+	return major == wantMajor && minor >= wantMinor
+}
+
+// majorMinor returns the major and minor numbers of a version such as "1.6.0" or "v1.6".
+func majorMinor(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,24 @@
+package v8dispatcher
+
+import "testing"
+
+func TestRuntimeVersion(t *testing.T) {
+	dist := newDispatcher(t)
+	v, err := dist.RuntimeVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, SupportedRuntimeVersion; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRuntimeVersionIncompatible(t *testing.T) {
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("old.js", `V8D.version = "0.9.1";`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dist.RuntimeVersion(); err == nil {
+		t.Error("error expected")
+	}
+}
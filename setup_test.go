@@ -27,3 +27,13 @@ func expectConsoleLogArgument(t *testing.T, rec *recorder, arg interface{}) {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 }
+
+func newDispatcher(t testing.TB, options ...Option) *MessageDispatcher {
+	t.Helper()
+	d, err := NewMessageDispatcher(options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
@@ -0,0 +1,160 @@
+package v8dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithTimers defines setTimeout, setInterval, clearTimeout and clearInterval in Javascript (see js/timers.js).
+// Timers are scheduled in Go and fire only while the dispatcher runs its event loop, see RunEventLoop.
+func WithTimers() Option {
+	return func(c *config) {
+		c.timers = true
+	}
+}
+
+// timer is a scheduled setTimeout or setInterval.
+type timer struct {
+	id       int
+	due      time.Time
+	interval time.Duration
+	repeat   bool
+}
+
+// timerQueue is the MessageSendHandler for "V8D.timers" that keeps all scheduled timers.
+type timerQueue struct {
+	mutex  sync.Mutex
+	timers map[int]*timer
+	now    func() time.Time
+}
+
+func newTimerQueue() *timerQueue {
+	return &timerQueue{timers: map[int]*timer{}, now: time.Now}
+}
+
+// Perform is part of MessageSendHandler.
+func (q *timerQueue) Perform(msg MessageSend) (interface{}, error) {
+	if len(msg.Arguments) == 0 {
+		return nil, fmt.Errorf("missing timer id")
+	}
+	id, ok := msg.Arguments[0].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid timer id: %v", msg.Arguments[0])
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	switch msg.Selector {
+	case "start":
+		if len(msg.Arguments) != 3 {
+			return nil, fmt.Errorf("start expects id, milliseconds and repeat")
+		}
+		ms, _ := msg.Arguments[1].(float64)
+		repeat, _ := msg.Arguments[2].(bool)
+		if ms < 0 {
+			ms = 0
+		}
+		interval := time.Duration(ms) * time.Millisecond
+		q.timers[int(id)] = &timer{id: int(id), due: q.now().Add(interval), interval: interval, repeat: repeat}
+	case "stop":
+		delete(q.timers, int(id))
+	default:
+		return nil, fmt.Errorf("unknown selector: %s", msg.Selector)
+	}
+	return nil, nil
+}
+
+// next returns the timer that is due first; timers due at the same time are ordered by id.
+func (q *timerQueue) next() (timer, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var first *timer
+	for _, each := range q.timers {
+		if first == nil || each.due.Before(first.due) || (each.due.Equal(first.due) && each.id < first.id) {
+			first = each
+		}
+	}
+	if first == nil {
+		return timer{}, false
+	}
+	return *first, true
+}
+
+// fired removes a timeout or schedules the next time of an interval.
+func (q *timerQueue) fired(t timer) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	scheduled, ok := q.timers[t.id]
+	if !ok {
+		return
+	}
+	if !scheduled.repeat {
+		delete(q.timers, t.id)
+		return
+	}
+	// an interval of 0 would starve the loop
+	scheduled.due = q.now().Add(max(scheduled.interval, time.Millisecond))
+}
+
+// clear removes all timers.
+func (q *timerQueue) clear() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.timers = map[int]*timer{}
+}
+
+// size returns the number of scheduled timers.
+func (q *timerQueue) size() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.timers)
+}
+
+// PendingTimers returns the number of scheduled timeouts and intervals.
+func (d *MessageDispatcher) PendingTimers() int {
+	if d.timers == nil {
+		return 0
+	}
+	return d.timers.size()
+}
+
+// RunEventLoop fires timers when they are due until no timers are pending or the context is done.
+// It returns the error of a failing timer function or the error of the context.
+// The loop must run on the goroutine that uses the dispatcher; it returns immediately if WithTimers was not used.
+// With WithDeterminism, the loop does not wait but advances the virtual clock to the next timer.
+func (d *MessageDispatcher) RunEventLoop(ctx context.Context) error {
+	if d.timers == nil {
+		return nil
+	}
+	for {
+		if d.closed {
+			return ErrClosed
+		}
+		t, ok := d.timers.next()
+		if !ok {
+			return nil
+		}
+		if wait := t.due.Sub(d.timers.now()); wait > 0 && d.clock != nil {
+			// virtual time does not wait
+			d.clock.moveTo(t.due)
+		} else if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.fireTimer(t); err != nil {
+			return err
+		}
+	}
+}
+
+// fireTimer calls the Javascript function of the timer.
+func (d *MessageDispatcher) fireTimer(t timer) error {
+	d.timers.fired(t)
+	return d.Call("V8D.timers", "fire", t.id)
+}
@@ -0,0 +1,46 @@
+package v8dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunEventLoop(t *testing.T) {
+	dist := newDispatcher(t, WithTimers())
+	if err := dist.Worker().Load("TestRunEventLoop.js", `
+		var fired = [];
+		setTimeout(function(what) { fired.push(what); }, 20, "second");
+		setTimeout(function() { fired.push("first"); }, 0);
+		var ticks = 0;
+		var interval = setInterval(function() {
+			ticks++;
+			if (ticks == 3) { clearInterval(interval); }
+		}, 1);
+		var cancelled = setTimeout(function() { fired.push("cancelled"); }, 5);
+		clearTimeout(cancelled);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dist.PendingTimers(), 3; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := dist.RunEventLoop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fired, _ := dist.Get("fired")
+	if got, want := len(fired.([]interface{})), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := fired.([]interface{})[0], "first"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if ticks, _ := dist.Get("ticks"); ticks != float64(3) {
+		t.Errorf("got %v want 3", ticks)
+	}
+	if got, want := dist.PendingTimers(), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package v8dispatcher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Span describes one MessageSend, handled or sent by a dispatcher, as part of a trace.
+// The trace and span identifiers are carried in the MessageSend such that spans created for
+// messages sent while handling another message become its children, across the Go/Javascript boundary.
+type Span struct {
+	TraceID string
+	SpanID  string
+	// ParentID is empty for a root span.
+	ParentID  string
+	Name      string
+	Receiver  string
+	Selector  string
+	Direction string
+	Start     time.Time
+	End       time.Time
+	// Err is non-nil if the MessageSend failed.
+	Err error
+}
+
+// Duration returns the time between Start and End.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SpanExporter receives each Span when it ends.
+// Implementations must be safe for concurrent use.
+type SpanExporter interface {
+	Export(Span)
+}
+
+// WithTracing makes the dispatcher create a Span for each MessageSend and export it.
+func WithTracing(exporter SpanExporter) Option {
+	return func(c *config) {
+		c.spanExporter = exporter
+	}
+}
+
+// MemoryExporter is a SpanExporter that keeps all spans in memory; useful for tests.
+type MemoryExporter struct {
+	mutex sync.Mutex
+	spans []Span
+}
+
+// Export is part of SpanExporter.
+func (e *MemoryExporter) Export(s Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, s)
+}
+
+// Spans returns all exported spans in the order they ended.
+func (e *MemoryExporter) Spans() []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]Span{}, e.spans...)
+}
+
+// Reset removes all exported spans.
+func (e *MemoryExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = nil
+}
+
+// tracer keeps the spans that are in progress; handling of MessageSends is nested, so these form a stack.
+type tracer struct {
+	exporter SpanExporter
+	mutex    sync.Mutex
+	active   []*Span
+}
+
+// start returns a new active span for the message.
+// For inbound messages, the parent is taken from the message; otherwise it is the innermost active span.
+func (t *tracer) start(msg MessageSend, direction string) *Span {
+	name := msg.Selector
+	if len(msg.Receiver) > 0 {
+		name = msg.Receiver + "." + msg.Selector
+	}
+	s := &Span{
+		SpanID:    newTraceIdentifier(8),
+		Name:      name,
+		Receiver:  msg.Receiver,
+		Selector:  msg.Selector,
+		Direction: direction,
+		Start:     time.Now(),
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if direction == directionInbound && len(msg.TraceID) > 0 {
+		s.TraceID = msg.TraceID
+		s.ParentID = msg.SpanID
+	} else if direction == directionOutbound && len(t.active) > 0 {
+		parent := t.active[len(t.active)-1]
+		s.TraceID = parent.TraceID
+		s.ParentID = parent.SpanID
+	} else {
+		s.TraceID = newTraceIdentifier(16)
+	}
+	t.active = append(t.active, s)
+	return s
+}
+
+// end removes the span from the active ones and exports it.
+func (t *tracer) end(s *Span, err error) {
+	s.End = time.Now()
+	s.Err = err
+	t.mutex.Lock()
+	for i := len(t.active) - 1; i >= 0; i-- {
+		if t.active[i] == s {
+			t.active = append(t.active[:i], t.active[i+1:]...)
+			break
+		}
+	}
+	t.mutex.Unlock()
+	t.exporter.Export(*s)
+}
+
+// startSpan returns a new span for the message or nil if tracing is not enabled.
+func (d *MessageDispatcher) startSpan(msg MessageSend, direction string) *Span {
+	if d.tracer == nil {
+		return nil
+	}
+	return d.tracer.start(msg, direction)
+}
+
+// endSpan ends and exports the span, if any.
+func (d *MessageDispatcher) endSpan(s *Span, err error) {
+	if s == nil {
+		return
+	}
+	d.tracer.end(s, err)
+}
+
+func newTraceIdentifier(size int) string {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		// cannot happen; crypto/rand.Read does not fail
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
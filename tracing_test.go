@@ -0,0 +1,50 @@
+package v8dispatcher
+
+import "testing"
+
+func TestTracingNestedSpans(t *testing.T) {
+	exporter := new(MemoryExporter)
+	dist := newDispatcher(t, WithTracing(exporter))
+	dist.RegisterFunc("someApi.tomorrow", func(msg MessageSend) (interface{}, error) {
+		return dist.CallReturn("this", "today")
+	})
+	if err := dist.Worker().Load("TestTracingNestedSpans.js", `
+		function today() { return "monday"; }
+		function plan() { return V8D.callReturn("someApi","tomorrow"); }
+	`); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+	v, err := dist.CallReturn("this", "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "monday"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	spans := map[string]Span{}
+	for _, each := range exporter.Spans() {
+		spans[each.Name] = each
+	}
+	plan, tomorrow, today := spans["this.plan"], spans["someApi.tomorrow"], spans["this.today"]
+	if got, want := len(spans), 3; got != want {
+		t.Fatalf("got %v want %v: %v", got, want, exporter.Spans())
+	}
+	if len(plan.ParentID) != 0 {
+		t.Error("root span expected")
+	}
+	if got, want := tomorrow.ParentID, plan.SpanID; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := tomorrow.Direction, "inbound"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := today.ParentID, tomorrow.SpanID; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	for _, each := range []Span{tomorrow, today} {
+		if got, want := each.TraceID, plan.TraceID; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package v8dispatcher
+
+import "fmt"
+
+// ReplyError is returned if the JSON reply of Javascript cannot be decoded into the expected Go value.
+type ReplyError struct {
+	// Receiver and Selector identify the MessageSend.
+	Receiver, Selector string
+	// Reply is the JSON returned by Javascript.
+	Reply string
+	// Err is the decode error.
+	Err error
+}
+
+func (e *ReplyError) Error() string {
+	reply := e.Reply
+	if len(reply) > 100 {
+		reply = reply[:100] + "..."
+	}
+	return fmt.Sprintf("reply of %s.%s: %v (reply: %s)", e.Receiver, e.Selector, e.Err, reply)
+}
+
+// Unwrap returns the decode error.
+func (e *ReplyError) Unwrap() error { return e.Err }
+
+// CallReturnAs is a synchronous call to Javascript, like CallReturn, that decodes the return value into a T.
+// A *ReplyError is returned if the value does not match the shape of T; a null value results in the zero T.
+func CallReturnAs[T any](d *MessageDispatcher, receiver string, method string, arguments ...interface{}) (T, error) {
+	var value T
+	err := d.sendInto(MessageSend{
+		Receiver:       receiver,
+		Selector:       method,
+		Arguments:      arguments,
+		IsAsynchronous: false,
+	}, &value)
+	return value, err
+}
+
+// GetAs returns the value for the global variable in Javascript, like Get, decoded into a T.
+// A *ReplyError is returned if the value does not match the shape of T; a null value results in the zero T.
+func GetAs[T any](d *MessageDispatcher, variableName string) (T, error) {
+	return CallReturnAs[T](d, "V8D", "get", variableName)
+}
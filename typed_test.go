@@ -0,0 +1,61 @@
+package v8dispatcher
+
+import (
+	"errors"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+	Name string `json:"name"`
+}
+
+func TestCallReturnAs(t *testing.T) {
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("typed.js", `
+	function origin(name) { return {"X": 0, "Y": 2, "name": name}; }
+	function names() { return ["a", "b"]; }
+	var count = 3;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	p, err := CallReturnAs[point](dist, "this", "origin", "home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p, (point{X: 0, Y: 2, Name: "home"}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	names, err := CallReturnAs[[]string](dist, "this", "names")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(names), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	count, err := GetAs[int](dist, "count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := count, 3; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestCallReturnAsMismatch(t *testing.T) {
+	dist := newDispatcher(t)
+	if err := dist.Worker().Load("typed.js", `var label = "three";`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := GetAs[int](dist, "label")
+	var replyErr *ReplyError
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("ReplyError expected, got %v", err)
+	}
+	if got, want := replyErr.Reply, `"three"`; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := replyErr.Selector, "get"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
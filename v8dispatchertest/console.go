@@ -0,0 +1,82 @@
+package v8dispatchertest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+// ConsoleEntry is one captured console MessageSend.
+type ConsoleEntry struct {
+	// Selector is the console function, e.g. "log" or "warn".
+	Selector  string
+	Arguments []interface{}
+	// Source and Line locate the caller in Javascript, if known.
+	Source string
+	Line   int
+}
+
+// Text returns the arguments separated by spaces.
+func (e ConsoleEntry) Text() string {
+	parts := make([]string, len(e.Arguments))
+	for i, each := range e.Arguments {
+		parts[i] = fmt.Sprint(each)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Console is a MessageSendHandler that captures all console MessageSends.
+type Console struct {
+	mutex   sync.Mutex
+	entries []ConsoleEntry
+}
+
+// CaptureConsole registers a new Console as the handler of the "console" receiver of the dispatcher.
+func CaptureConsole(d *v8dispatcher.MessageDispatcher) *Console {
+	c := new(Console)
+	d.Register("console", c)
+	return c
+}
+
+// Perform is part of v8dispatcher.MessageSendHandler.
+func (c *Console) Perform(msg v8dispatcher.MessageSend) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = append(c.entries, ConsoleEntry{
+		Selector:  msg.Selector,
+		Arguments: msg.Arguments,
+		Source:    msg.Source,
+		Line:      msg.Line,
+	})
+	return nil, nil
+}
+
+// Entries returns all captured entries.
+func (c *Console) Entries() []ConsoleEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]ConsoleEntry{}, c.entries...)
+}
+
+// Lines returns the text of all captured entries.
+func (c *Console) Lines() []string {
+	lines := []string{}
+	for _, each := range c.Entries() {
+		lines = append(lines, each.Text())
+	}
+	return lines
+}
+
+// AssertLogged fails the test if no captured entry contains the text.
+func (c *Console) AssertLogged(t testing.TB, text string) {
+	t.Helper()
+	for _, each := range c.Lines() {
+		if strings.Contains(each, text) {
+			return
+		}
+	}
+	t.Errorf("%q not logged, got %q", text, c.Lines())
+}
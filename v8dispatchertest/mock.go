@@ -0,0 +1,268 @@
+/*
+Package v8dispatchertest provides utilities for testing Javascript that uses a v8dispatcher.MessageDispatcher.
+
+A Mock answers MessageSends from Javascript using expectations and records them for assertions:
+
+	d, mock := v8dispatchertest.NewDispatcher(t)
+	mock.Expect("someApi", "now").Return("2016-02-08")
+	d.Worker().Load("test.js", `var now = V8D.callReturn("someApi","now");`)
+	mock.AssertCalledTimes(t, "someApi", "now", 1)
+	mock.AssertNoUnhandled(t)
+
+A Console captures all console messages from Javascript:
+
+	console := v8dispatchertest.CaptureConsole(d)
+	...
+	console.AssertLogged(t, "ready")
+*/
+package v8dispatchertest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+// Mock is a MessageSendHandler that answers MessageSends using expectations and records all of them.
+// It is also a v8dispatcher.Metrics to detect MessageSends for which the dispatcher has no handler at all.
+type Mock struct {
+	mutex        sync.Mutex
+	dispatcher   *v8dispatcher.MessageDispatcher
+	registered   map[string]bool
+	expectations []*Expectation
+	messages     []v8dispatcher.MessageSend
+	unhandled    []v8dispatcher.MessageSend
+}
+
+// NewDispatcher returns a new MessageDispatcher, created with the options, and a Mock for it.
+// Metrics passed with WithMetrics in the options still receive all observations.
+// The test fails if the dispatcher cannot be created; the dispatcher is closed when the test ends.
+func NewDispatcher(t testing.TB, options ...v8dispatcher.Option) (*v8dispatcher.MessageDispatcher, *Mock) {
+	t.Helper()
+	m := &Mock{registered: map[string]bool{}}
+	options = append(append([]v8dispatcher.Option{}, options...), v8dispatcher.WithMetrics(m))
+	d, err := v8dispatcher.NewMessageDispatcher(options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.dispatcher = d
+	t.Cleanup(func() { d.Close() })
+	return d, m
+}
+
+// NewMock returns a Mock for an existing dispatcher.
+// Only MessageSends for the receivers and selectors of its expectations are detected as unhandled; see NewDispatcher.
+func NewMock(d *v8dispatcher.MessageDispatcher) *Mock {
+	return &Mock{dispatcher: d, registered: map[string]bool{}}
+}
+
+// Expect returns a new Expectation for a MessageSend and registers the Mock as its handler.
+// If the receiver is empty then the Mock is registered as function for the selector.
+func (m *Mock) Expect(receiver, selector string) *Expectation {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e := &Expectation{receiver: receiver, selector: selector, times: -1}
+	m.expectations = append(m.expectations, e)
+	if len(receiver) == 0 {
+		if !m.registered["."+selector] {
+			m.dispatcher.RegisterFunc(selector, m.Perform)
+			m.registered["."+selector] = true
+		}
+	} else if !m.registered[receiver] {
+		m.dispatcher.Register(receiver, m)
+		m.registered[receiver] = true
+	}
+	return e
+}
+
+// Perform is part of v8dispatcher.MessageSendHandler.
+// It answers the MessageSend using the first matching expectation that has calls left.
+func (m *Mock) Perform(msg v8dispatcher.MessageSend) (interface{}, error) {
+	m.mutex.Lock()
+	m.messages = append(m.messages, msg)
+	var match *Expectation
+	for _, each := range m.expectations {
+		if each.matches(msg) && (each.times < 0 || each.calls < each.times) {
+			each.calls++
+			match = each
+			break
+		}
+	}
+	if match == nil {
+		m.unhandled = append(m.unhandled, msg)
+	}
+	m.mutex.Unlock()
+	if match == nil {
+		return nil, v8dispatcher.ErrNoHandler
+	}
+	// unlocked; the handler may cause nested MessageSends
+	if match.result != nil {
+		return match.result(msg)
+	}
+	return match.value, match.err
+}
+
+// Observe is part of v8dispatcher.Metrics. It records MessageSends for which the dispatcher has no handler.
+func (m *Mock) Observe(o v8dispatcher.Observation) {
+	if o.Direction != "inbound" || !errors.Is(o.Err, v8dispatcher.ErrNoHandler) {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.registered[o.Receiver] || (len(o.Receiver) == 0 && m.registered["."+o.Selector]) {
+		// already recorded by Perform
+		return
+	}
+	m.unhandled = append(m.unhandled, v8dispatcher.MessageSend{Receiver: o.Receiver, Selector: o.Selector})
+}
+
+// Messages returns all MessageSends performed by the Mock.
+func (m *Mock) Messages() []v8dispatcher.MessageSend {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]v8dispatcher.MessageSend{}, m.messages...)
+}
+
+// Calls returns the MessageSends performed by the Mock for the receiver and selector.
+func (m *Mock) Calls(receiver, selector string) []v8dispatcher.MessageSend {
+	calls := []v8dispatcher.MessageSend{}
+	for _, each := range m.Messages() {
+		if each.Receiver == receiver && each.Selector == selector {
+			calls = append(calls, each)
+		}
+	}
+	return calls
+}
+
+// Unhandled returns the MessageSends that matched no expectation or had no handler.
+func (m *Mock) Unhandled() []v8dispatcher.MessageSend {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]v8dispatcher.MessageSend{}, m.unhandled...)
+}
+
+// AssertCalledWith fails the test if no MessageSend for the receiver and selector had the arguments.
+// Arguments are compared by their JSON representation.
+func (m *Mock) AssertCalledWith(t testing.TB, receiver, selector string, arguments ...interface{}) {
+	t.Helper()
+	calls := m.Calls(receiver, selector)
+	for _, each := range calls {
+		if argumentsEqual(arguments, each.Arguments) {
+			return
+		}
+	}
+	t.Errorf("%s.%s not called with arguments %v, calls: %v", receiver, selector, arguments, argumentsOf(calls))
+}
+
+// AssertCalledTimes fails the test if the number of MessageSends for the receiver and selector is not n.
+func (m *Mock) AssertCalledTimes(t testing.TB, receiver, selector string, n int) {
+	t.Helper()
+	if got := len(m.Calls(receiver, selector)); got != n {
+		t.Errorf("%s.%s called %d times, want %d", receiver, selector, got, n)
+	}
+}
+
+// AssertNoUnhandled fails the test if any MessageSend matched no expectation or had no handler.
+func (m *Mock) AssertNoUnhandled(t testing.TB) {
+	t.Helper()
+	for _, each := range m.Unhandled() {
+		t.Errorf("unhandled MessageSend %s.%s with arguments %v", each.Receiver, each.Selector, each.Arguments)
+	}
+}
+
+// AssertExpectations fails the test if an expectation with a number of Times was called less often.
+func (m *Mock) AssertExpectations(t testing.TB) {
+	t.Helper()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, each := range m.expectations {
+		if each.times >= 0 && each.calls != each.times {
+			t.Errorf("%s.%s called %d times, want %d", each.receiver, each.selector, each.calls, each.times)
+		}
+	}
+}
+
+// Expectation describes how a Mock answers a MessageSend.
+type Expectation struct {
+	receiver  string
+	selector  string
+	arguments []interface{}
+	matchArgs bool
+	value     interface{}
+	err       error
+	result    v8dispatcher.MessageSendHandlerFunc
+	times     int
+	calls     int
+}
+
+// WithArguments restricts the expectation to MessageSends with these arguments, compared by their JSON representation.
+func (e *Expectation) WithArguments(arguments ...interface{}) *Expectation {
+	e.arguments = arguments
+	e.matchArgs = true
+	return e
+}
+
+// Return sets the value that is returned to Javascript.
+func (e *Expectation) Return(value interface{}) *Expectation {
+	e.value = value
+	return e
+}
+
+// ReturnError sets the error that is reported for the MessageSend.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Do sets a function that performs the MessageSend instead of returning a fixed value.
+func (e *Expectation) Do(handler v8dispatcher.MessageSendHandlerFunc) *Expectation {
+	e.result = handler
+	return e
+}
+
+// Times limits the number of MessageSends answered by this expectation; see Mock.AssertExpectations.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) matches(msg v8dispatcher.MessageSend) bool {
+	if msg.Receiver != e.receiver || msg.Selector != e.selector {
+		return false
+	}
+	return !e.matchArgs || argumentsEqual(e.arguments, msg.Arguments)
+}
+
+// argumentsEqual compares the expected arguments with the actual by their JSON representation.
+func argumentsEqual(expected, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	return reflect.DeepEqual(normalized(expected), normalized(actual))
+}
+
+// normalized returns the value as decoded from its JSON representation.
+func normalized(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	var n interface{}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return n
+}
+
+func argumentsOf(calls []v8dispatcher.MessageSend) [][]interface{} {
+	list := [][]interface{}{}
+	for _, each := range calls {
+		list = append(list, each.Arguments)
+	}
+	return list
+}
@@ -0,0 +1,66 @@
+package v8dispatchertest
+
+import (
+	"testing"
+
+	"github.com/emicklei/v8dispatcher"
+)
+
+func TestMockExpectations(t *testing.T) {
+	d, mock := NewDispatcher(t)
+	console := CaptureConsole(d)
+	mock.Expect("someApi", "now").Return("monday").Times(2)
+	mock.Expect("", "handleEvent").WithArguments(map[string]interface{}{"size": 42})
+	if err := d.Worker().Load("test.js", `
+		console.log("today is", V8D.callReturn("someApi","now"));
+		V8D.callReturn("someApi","now");
+		V8D.call("","handleEvent",{"size":42});
+	`); err != nil {
+		t.Fatal(err)
+	}
+	mock.AssertCalledTimes(t, "someApi", "now", 2)
+	mock.AssertCalledWith(t, "", "handleEvent", map[string]interface{}{"size": 42})
+	mock.AssertExpectations(t)
+	mock.AssertNoUnhandled(t)
+	console.AssertLogged(t, "today is monday")
+	if got, want := console.Entries()[0].Source, "test.js"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestMockUnhandled(t *testing.T) {
+	d, mock := NewDispatcher(t)
+	mock.Expect("someApi", "now").Return("monday").Times(1)
+	if err := d.Worker().Load("test.js", `
+		V8D.callReturn("someApi","now");
+		V8D.callReturn("someApi","now");
+		V8D.call("other","unknown");
+	`); err != nil {
+		t.Fatal(err)
+	}
+	unhandled := mock.Unhandled()
+	if got, want := len(unhandled), 2; got != want {
+		t.Fatalf("got %v want %v: %v", got, want, unhandled)
+	}
+	if got, want := unhandled[1].Receiver, "other"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestMockKeepsMetrics(t *testing.T) {
+	metrics := v8dispatcher.NewMemoryMetrics()
+	options := make([]v8dispatcher.Option, 1, 2)
+	options[0] = v8dispatcher.WithMetrics(metrics)
+	d, mock := NewDispatcher(t, options...)
+	mock.Expect("someApi", "now").Return("monday")
+	if _, err := d.Eval("test.js", `V8D.callReturn("someApi","now")`); err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics.Snapshot()) == 0 {
+		t.Error("observations expected")
+	}
+	// the options of the caller are not changed
+	if options[:2][1] != nil {
+		t.Error("options changed")
+	}
+}
@@ -0,0 +1,143 @@
+package v8dispatcher
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher polls the scripts that were loaded into a MessageDispatcher using LoadFS or LoadDir.
+// On a change, it loads all these scripts into a fresh MessageDispatcher with the same registrations
+// and swaps it in. If loading fails then the previous dispatcher remains in use.
+// The Watcher does not close the swapped out dispatcher because another goroutine may still be using it;
+// it is passed to OnReload to be closed by the goroutine that uses the dispatchers.
+// Its OnClose functions are moved to the fresh dispatcher, because they release resources of the handlers
+// that the fresh dispatcher keeps using.
+// Scripts loaded by other means (e.g. Worker().Load) are not part of the fresh dispatcher.
+// Handlers must be registered before the Watcher is started.
+type Watcher struct {
+	current  atomic.Pointer[MessageDispatcher]
+	interval time.Duration
+	stamp    string
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// OnReload is called with the fresh dispatcher after it has been swapped in and with the previous one,
+	// which must be closed once it is no longer used. It is called on the goroutine of Check.
+	OnReload func(fresh, old *MessageDispatcher)
+
+	// OnError is called with the error of a failed reload. Default logs the error.
+	OnError func(error)
+}
+
+// NewWatcher returns a Watcher for the dispatcher that polls every interval.
+func NewWatcher(d *MessageDispatcher, interval time.Duration) *Watcher {
+	w := &Watcher{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	w.OnError = func(err error) {
+		w.Dispatcher().logger.Error("script reload failed", "err", err)
+	}
+	w.current.Store(d)
+	w.stamp, _ = d.scriptStamp()
+	return w
+}
+
+// Dispatcher returns the dispatcher that is currently in use.
+func (w *Watcher) Dispatcher() *MessageDispatcher {
+	return w.current.Load()
+}
+
+// Start begins polling in a new goroutine.
+func (w *Watcher) Start() {
+	go w.loop()
+}
+
+// Stop ends polling. It is safe to call Stop more than once.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *Watcher) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check reloads the scripts if any of them has changed since the last check.
+// It returns true if a fresh dispatcher was swapped in.
+func (w *Watcher) Check() bool {
+	old := w.Dispatcher()
+	stamp, err := old.scriptStamp()
+	if err != nil {
+		w.OnError(err)
+		return false
+	}
+	if stamp == w.stamp {
+		return false
+	}
+	// do not retry until the next change
+	w.stamp = stamp
+	fresh, err := old.clone()
+	if err != nil {
+		w.OnError(err)
+		return false
+	}
+	for _, each := range old.scriptSources {
+		if err := fresh.loadSource(each, true); err != nil {
+			fresh.discard()
+			w.OnError(err)
+			return false
+		}
+	}
+	w.current.Store(fresh)
+	if old.traceEnabled {
+		old.logger.Info("scripts reloaded", "sources", len(old.scriptSources))
+	}
+	// the OnClose functions now belong to the fresh dispatcher
+	old.closeHooks = nil
+	if w.OnReload != nil {
+		w.OnReload(fresh, old)
+	}
+	return true
+}
+
+// discard closes the dispatcher without calling its OnClose functions, which belong to another dispatcher as well.
+func (d *MessageDispatcher) discard() {
+	d.closeHooks = nil
+	d.Close()
+}
+
+// scriptStamp returns a value that changes if any file-based script is added, removed or modified.
+func (d *MessageDispatcher) scriptStamp() (string, error) {
+	stamp := ""
+	for _, src := range d.scriptSources {
+		names, err := scriptNames(src.fsys, src.patterns)
+		if err != nil {
+			return "", err
+		}
+		if len(src.patterns) == 0 {
+			// manifest changes affect the order
+			names = append(names, ManifestName)
+		}
+		for _, each := range names {
+			info, err := fs.Stat(src.fsys, each)
+			if err != nil {
+				stamp += fmt.Sprintf("%s:-;", each)
+				continue
+			}
+			stamp += fmt.Sprintf("%s:%d:%d;", each, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return stamp, nil
+}
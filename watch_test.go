@@ -0,0 +1,163 @@
+package v8dispatcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "greet.js")
+	if err := os.WriteFile(file, []byte(`function greet() { return "hello"; }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := newDispatcher(t)
+	dist.RegisterFunc("name", func(msg MessageSend) (interface{}, error) {
+		return "world", nil
+	})
+	if err := dist.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	closed := 0
+	dist.OnClose(func() { closed++ })
+	w := NewWatcher(dist, time.Hour)
+	var reloadErr error
+	w.OnError = func(err error) { reloadErr = err }
+	var previous *MessageDispatcher
+	w.OnReload = func(fresh, old *MessageDispatcher) { previous = old }
+	if w.Check() {
+		t.Fatal("unexpected reload")
+	}
+
+	// broken script keeps the previous version
+	if err := os.WriteFile(file, []byte(`function greet() { return ; ;; }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w.Check() {
+		t.Fatal("unexpected reload")
+	}
+	if reloadErr == nil {
+		t.Error("error expected")
+	}
+	if got, want := w.Dispatcher(), dist; got != want {
+		t.Error("previous dispatcher expected")
+	}
+
+	// fixed script is loaded in a fresh dispatcher that has the registered handlers
+	if err := os.WriteFile(file, []byte(`function greet() { return "hello " + V8D.callReturn("","name"); }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Check() {
+		t.Fatal("reload expected")
+	}
+	v, err := w.Dispatcher().CallReturn("this", "greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "hello world"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// the previous dispatcher is handed over to be closed and its OnClose functions moved to the fresh one
+	if got, want := previous, dist; got != want {
+		t.Error("previous dispatcher expected")
+	}
+	if dist.Closed() {
+		t.Error("open previous dispatcher expected")
+	}
+	dist.Close()
+	if got, want := closed, 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	w.Dispatcher().Close()
+	if got, want := closed, 1; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWatcherReloadedRuntime(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "later.js")
+	if err := os.WriteFile(file, []byte(`var result = "";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := newDispatcher(t, WithTimers())
+	dist.RegisterFunc("name", func(msg MessageSend) (interface{}, error) {
+		return "world", nil
+	})
+	if err := dist.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(dist, time.Hour)
+	if err := os.WriteFile(file, []byte(`var result = "hello";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Check() {
+		t.Fatal("reload expected")
+	}
+	fresh := w.Dispatcher()
+	t.Cleanup(func() { fresh.Close() })
+	// function references and timers are issued by the fresh dispatcher
+	if _, err := fresh.Eval("later.js", `
+	V8D.callThen("", "name", function(name) { result += " " + name; });
+	setTimeout(function() { result += "!"; }, 1);`); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.RunEventLoop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	v, err := fresh.Eval("result.js", `result`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "hello world!"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWatcherSwapWhileCalling(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "greet.js")
+	if err := os.WriteFile(file, []byte(`function greet() { return 0; }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := newDispatcher(t)
+	if err := dist.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(dist, time.Hour)
+	retired := make(chan *MessageDispatcher, 10)
+	w.OnReload = func(fresh, old *MessageDispatcher) { retired <- old }
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 200; i++ {
+			if _, err := w.Dispatcher().CallReturn("this", "greet"); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := 1; i <= 5; i++ {
+		source := fmt.Sprintf("function greet() { return %d; }%s", i, strings.Repeat(" ", i))
+		if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if !w.Check() {
+			t.Fatal("reload expected")
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// the calling goroutine has ended, so the previous dispatchers can be closed
+	close(retired)
+	for each := range retired {
+		each.Close()
+	}
+	w.Dispatcher().Close()
+}